@@ -22,6 +22,16 @@ import (
 // CredentialAuthenticator is the interface for authenticating a client using credential.
 type CredentialAuthenticator = auth.CredentialAuthenticator
 
+// CredentialPrincipalAuthenticator is the interface for authenticating a client using credential and resolving the principal.
+type CredentialPrincipalAuthenticator interface {
+	CredentialAuthenticator
+	// VerifyCredentialPrincipal verifies the client credential and returns the authenticated principal.
+	VerifyCredentialPrincipal(conn Conn, q Query) (Principal, bool, error)
+}
+
+// CredentialStoreRegistrar is the interface for setting the credential store.
+type CredentialStoreRegistrar = auth.CredentialStoreRegistrar
+
 // DefaultCredentialAuthenticator is the default credential authenticator.
 type DefaultCredentialAuthenticator = auth.DefaultCredentialAuthenticator
 
@@ -30,3 +40,12 @@ type CertificateAuthenticator interface {
 	// VerifyCertificate verifies the client certificate.
 	VerifyCertificate(conn tls.Conn) (bool, error)
 }
+
+// CertificatePrincipalAuthenticator is the interface for authenticating a client using TLS certificates and resolving the principal.
+type CertificatePrincipalAuthenticator interface {
+	CertificateAuthenticator
+	// VerifyCertificatePrincipal verifies the client certificate and returns the authenticated principal.
+	VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error)
+	// VerifyCertificateQuery verifies the client certificate and checks that the principal matches the queried username and group.
+	VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error)
+}
@@ -18,7 +18,66 @@ import (
 	"github.com/cybergarage/go-sasl/sasl/auth"
 )
 
-// NewCredentialAuthenticator returns a new credential authenticator.
+type credentialAuthenticator struct {
+	credStore CredentialStore
+}
+
+// NewCredentialAuthenticator returns a new credential authenticator which verifies the client credential in the same way
+// as the default credential authenticator of go-sasl, and resolves the principal from the credential it looked up.
+// As the default credential authenticator, it admits every client until the credential store is set.
 func NewCredentialAuthenticator() DefaultCredentialAuthenticator {
-	return auth.NewDefaultCredentialAuthenticator()
+	return &credentialAuthenticator{
+		credStore: nil,
+	}
+}
+
+// SetCredentialStore sets the credential store.
+func (ca *credentialAuthenticator) SetCredentialStore(credStore CredentialStore) {
+	ca.credStore = credStore
+}
+
+// CredentialStore returns the credential store.
+func (ca *credentialAuthenticator) CredentialStore() CredentialStore {
+	return ca.credStore
+}
+
+// VerifyCredential verifies the client credential.
+func (ca *credentialAuthenticator) VerifyCredential(conn Conn, q Query) (bool, error) {
+	_, ok, err := ca.VerifyCredentialPrincipal(conn, q)
+	return ok, err
+}
+
+// VerifyCredentialPrincipal verifies the client credential and returns the authenticated principal.
+// The group of the principal is taken from the looked-up credential rather than the client query.
+func (ca *credentialAuthenticator) VerifyCredentialPrincipal(conn Conn, q Query) (Principal, bool, error) {
+	if ca.credStore == nil {
+		return newCredentialPrincipal(q, nil), true, nil
+	}
+	cred, ok, err := ca.credStore.LookupCredential(q)
+	if !ok {
+		return nil, false, err
+	}
+	ok, err = verifyCredentialDefault(conn, q, cred)
+	if !ok {
+		return nil, false, err
+	}
+	return newCredentialPrincipal(q, cred), true, err
+}
+
+// verifyCredentialDefault verifies the client credential against the looked-up credential by the default credential
+// authenticator of go-sasl, so that the credential is looked up only once.
+func verifyCredentialDefault(conn Conn, q Query, cred Credential) (bool, error) {
+	verifier := auth.NewDefaultCredentialAuthenticator()
+	verifier.SetCredentialStore(&resolvedCredentialStore{cred: cred})
+	return verifier.VerifyCredential(conn, q)
+}
+
+// resolvedCredentialStore is a credential store which returns the already looked-up credential.
+type resolvedCredentialStore struct {
+	cred Credential
+}
+
+// LookupCredential returns the looked-up credential.
+func (store *resolvedCredentialStore) LookupCredential(q Query) (Credential, bool, error) {
+	return store.cred, true, nil
 }
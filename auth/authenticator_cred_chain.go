@@ -0,0 +1,343 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CredentialControl represents the control flag of an entry in a credential authenticator chain.
+// The flags follow the semantics of the PAM control flags.
+type CredentialControl int
+
+const (
+	// CredentialRequired requires the entry to succeed. If it fails, the chain fails,
+	// but the remaining entries are still evaluated so that the failing entry is not revealed.
+	CredentialRequired CredentialControl = iota
+	// CredentialRequisite requires the entry to succeed. If it fails, the chain fails immediately.
+	CredentialRequisite
+	// CredentialSufficient admits the client immediately if the entry succeeds and no required entry has failed.
+	// A failure of the entry is ignored.
+	CredentialSufficient
+	// CredentialOptional admits the client only if no required or requisite entry has failed
+	// and no other entry has succeeded. A failure of the entry is ignored.
+	CredentialOptional
+)
+
+// String returns the string representation of the control flag.
+func (c CredentialControl) String() string {
+	switch c {
+	case CredentialRequired:
+		return "required"
+	case CredentialRequisite:
+		return "requisite"
+	case CredentialSufficient:
+		return "sufficient"
+	case CredentialOptional:
+		return "optional"
+	}
+	return "unknown"
+}
+
+// CredentialChainEntry represents an entry of a credential authenticator chain.
+type CredentialChainEntry struct {
+	// Name identifies the entry in the decision trace.
+	Name          string
+	Control       CredentialControl
+	Authenticator CredentialAuthenticator
+	// Store is the credential store of the entry. If it is nil, an authenticator which implements CredentialStoreRegistrar
+	// and has no store of its own is given the store set by SetCredentialStore of the chain.
+	Store CredentialStore
+}
+
+// WithStore returns a copy of the entry with its own credential store.
+func (entry CredentialChainEntry) WithStore(store CredentialStore) CredentialChainEntry {
+	entry.Store = store
+	return entry
+}
+
+// RequiredCredential returns a chain entry which must succeed.
+func RequiredCredential(name string, auth CredentialAuthenticator) CredentialChainEntry {
+	return CredentialChainEntry{Name: name, Control: CredentialRequired, Authenticator: auth, Store: nil}
+}
+
+// RequisiteCredential returns a chain entry which must succeed and stops the chain if it fails.
+func RequisiteCredential(name string, auth CredentialAuthenticator) CredentialChainEntry {
+	return CredentialChainEntry{Name: name, Control: CredentialRequisite, Authenticator: auth, Store: nil}
+}
+
+// SufficientCredential returns a chain entry which admits the client if it succeeds.
+func SufficientCredential(name string, auth CredentialAuthenticator) CredentialChainEntry {
+	return CredentialChainEntry{Name: name, Control: CredentialSufficient, Authenticator: auth, Store: nil}
+}
+
+// OptionalCredential returns a chain entry whose result matters only if no other entry decides.
+func OptionalCredential(name string, auth CredentialAuthenticator) CredentialChainEntry {
+	return CredentialChainEntry{Name: name, Control: CredentialOptional, Authenticator: auth, Store: nil}
+}
+
+// CredentialDecision represents the result of an entry evaluated by a credential authenticator chain.
+type CredentialDecision struct {
+	Name    string
+	Control CredentialControl
+	Result  bool
+	Err     error
+}
+
+// String returns the string representation of the decision.
+func (d CredentialDecision) String() string {
+	result := "success"
+	if !d.Result {
+		result = "failure"
+	}
+	if d.Err != nil {
+		result = fmt.Sprintf("%s (%v)", result, d.Err)
+	}
+	return fmt.Sprintf("%s[%s]: %s", d.Name, d.Control, result)
+}
+
+// CredentialTrace represents the decision trace of a credential authenticator chain.
+type CredentialTrace struct {
+	// Decisions holds the decisions of the evaluated entries in order. The entries skipped by the chain are not included.
+	Decisions []CredentialDecision
+	// Result is the final result of the chain.
+	Result bool
+	// Reason describes why the chain reached the result.
+	Reason string
+	// Principal is the principal authenticated by the first deciding entry, or nil if the verification failed.
+	Principal Principal
+}
+
+// String returns the string representation of the trace.
+func (trace *CredentialTrace) String() string {
+	decisions := make([]string, len(trace.Decisions))
+	for n, d := range trace.Decisions {
+		decisions[n] = d.String()
+	}
+	result := "success"
+	if !trace.Result {
+		result = "failure"
+	}
+	return fmt.Sprintf("%s: %s (%s)", result, trace.Reason, strings.Join(decisions, ", "))
+}
+
+// CredentialAuthenticatorChain is the interface for an ordered chain of credential authenticators.
+type CredentialAuthenticatorChain interface {
+	CredentialAuthenticator
+	CredentialStoreRegistrar
+	// Entries returns the entries of the chain.
+	Entries() []CredentialChainEntry
+	// VerifyCredentialTrace verifies the client credential and returns the decision trace.
+	VerifyCredentialTrace(conn Conn, q Query) (*CredentialTrace, error)
+}
+
+// credentialStoreProvider is the interface for an authenticator which reports its credential store.
+type credentialStoreProvider interface {
+	// CredentialStore returns the credential store.
+	CredentialStore() CredentialStore
+}
+
+type credentialChain struct {
+	entries []CredentialChainEntry
+	// shared holds whether each entry is given the store set by SetCredentialStore.
+	shared []bool
+	store  CredentialStore
+}
+
+// NewCredentialAuthenticatorChain returns a new credential authenticator chain which evaluates the entries in order.
+// An entry which implements CredentialStoreRegistrar is given its own store if the entry has one. Otherwise, unless the
+// authenticator reports a store of its own, it is given the store set by SetCredentialStore of the chain, which the
+// manager forwards, and the chain refuses to verify until the store is set. A chain with no entries rejects every client.
+func NewCredentialAuthenticatorChain(entries ...CredentialChainEntry) CredentialAuthenticatorChain {
+	chain := &credentialChain{
+		entries: append([]CredentialChainEntry{}, entries...),
+		shared:  make([]bool, len(entries)),
+		store:   nil,
+	}
+	for n, entry := range chain.entries {
+		reg, ok := entry.Authenticator.(CredentialStoreRegistrar)
+		if !ok {
+			continue
+		}
+		if entry.Store != nil {
+			reg.SetCredentialStore(entry.Store)
+			continue
+		}
+		if sp, ok := entry.Authenticator.(credentialStoreProvider); ok && sp.CredentialStore() != nil {
+			continue
+		}
+		chain.shared[n] = true
+	}
+	return chain
+}
+
+// SetCredentialStore sets the credential store of the entries which have no store of their own.
+func (chain *credentialChain) SetCredentialStore(store CredentialStore) {
+	chain.store = store
+	for n, entry := range chain.entries {
+		if !chain.shared[n] {
+			continue
+		}
+		if reg, ok := entry.Authenticator.(CredentialStoreRegistrar); ok {
+			reg.SetCredentialStore(store)
+		}
+	}
+}
+
+// Entries returns the entries of the chain.
+func (chain *credentialChain) Entries() []CredentialChainEntry {
+	return append([]CredentialChainEntry{}, chain.entries...)
+}
+
+// VerifyCredential verifies the client credential.
+func (chain *credentialChain) VerifyCredential(conn Conn, q Query) (bool, error) {
+	trace, err := chain.VerifyCredentialTrace(conn, q)
+	return trace.Result, err
+}
+
+// entryCredentialStore returns the credential store which the entry authenticator verifies the credential with, or nil if it is not known.
+func (chain *credentialChain) entryCredentialStore(n int) CredentialStore {
+	entry := chain.entries[n]
+	if entry.Store != nil {
+		return entry.Store
+	}
+	if chain.shared[n] {
+		return chain.store
+	}
+	return credentialStoreOf(entry.Authenticator, nil)
+}
+
+// credentialStoreOf returns the credential store reported by the authenticator, or the specified store if it reports none.
+func credentialStoreOf(auth CredentialAuthenticator, store CredentialStore) CredentialStore {
+	if sp, ok := auth.(credentialStoreProvider); ok && sp.CredentialStore() != nil {
+		return sp.CredentialStore()
+	}
+	return store
+}
+
+// verifyCredentialWith verifies the credential by the authenticator and returns the authenticated principal.
+// If the authenticator does not resolve the principal, the principal is built from the credential looked up by
+// the store which the authenticator verifies with. The principal has no group if the store is nil or has no credential.
+func verifyCredentialWith(auth CredentialAuthenticator, store CredentialStore, conn Conn, q Query) (Principal, bool, error) {
+	if pa, ok := auth.(CredentialPrincipalAuthenticator); ok {
+		return pa.VerifyCredentialPrincipal(conn, q)
+	}
+	ok, err := auth.VerifyCredential(conn, q)
+	if !ok {
+		return nil, false, err
+	}
+	if store == nil {
+		return newCredentialPrincipal(q, nil), true, err
+	}
+	cred, found, lookupErr := store.LookupCredential(q)
+	if lookupErr != nil {
+		return nil, false, lookupErr
+	}
+	if !found {
+		return newCredentialPrincipal(q, nil), true, err
+	}
+	return newCredentialPrincipal(q, cred), true, err
+}
+
+// VerifyCredentialTrace verifies the client credential and returns the decision trace.
+// The errors of the entries are recorded in the trace, and are returned joined only if the chain fails,
+// so that an unavailable source does not prevent a fallback to the next entry.
+func (chain *credentialChain) VerifyCredentialTrace(conn Conn, q Query) (*CredentialTrace, error) {
+	trace := &CredentialTrace{
+		Decisions: []CredentialDecision{},
+		Result:    false,
+		Reason:    "",
+		Principal: nil,
+	}
+	var errs []error
+	var failure string
+	var success string
+	var optional string
+	var optionalPrincipal Principal
+	finish := func(result bool, reason string) (*CredentialTrace, error) {
+		trace.Result = result
+		trace.Reason = reason
+		if result {
+			return trace, nil
+		}
+		trace.Principal = nil
+		return trace, errors.Join(errs...)
+	}
+	if chain.store == nil {
+		for n, entry := range chain.entries {
+			if chain.shared[n] {
+				errs = append(errs, fmt.Errorf("%s: %w", entry.Name, ErrNoCredentialStore))
+				return finish(false, fmt.Sprintf("entry %q has no credential store", entry.Name))
+			}
+		}
+	}
+	for n, entry := range chain.entries {
+		p, ok, err := verifyCredentialWith(entry.Authenticator, chain.entryCredentialStore(n), conn, q)
+		trace.Decisions = append(trace.Decisions, CredentialDecision{
+			Name:    entry.Name,
+			Control: entry.Control,
+			Result:  ok,
+			Err:     err,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name, err))
+		}
+		switch entry.Control {
+		case CredentialRequired, CredentialRequisite:
+			if !ok {
+				if len(failure) == 0 {
+					failure = fmt.Sprintf("%s entry %q failed", entry.Control, entry.Name)
+				}
+				if entry.Control == CredentialRequisite {
+					return finish(false, failure)
+				}
+				continue
+			}
+			if len(success) == 0 {
+				success = fmt.Sprintf("%s entry %q succeeded", entry.Control, entry.Name)
+			}
+			if trace.Principal == nil {
+				trace.Principal = p
+			}
+		case CredentialSufficient:
+			if !ok || len(failure) != 0 {
+				continue
+			}
+			if trace.Principal == nil {
+				trace.Principal = p
+			}
+			return finish(true, fmt.Sprintf("sufficient entry %q succeeded", entry.Name))
+		case CredentialOptional:
+			if ok && len(optional) == 0 {
+				optional = fmt.Sprintf("optional entry %q succeeded", entry.Name)
+				optionalPrincipal = p
+			}
+		}
+	}
+	switch {
+	case len(failure) != 0:
+		return finish(false, failure)
+	case len(success) != 0:
+		return finish(true, success)
+	case len(optional) != 0:
+		trace.Principal = optionalPrincipal
+		return finish(true, optional)
+	case len(chain.entries) == 0:
+		return finish(false, "no entries")
+	}
+	return finish(false, "no entry succeeded")
+}
@@ -0,0 +1,271 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/cybergarage/go-sasl/sasl/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	shaHashPrefix  = "{SHA}"
+	apr1HashPrefix = "$apr1$"
+	md5HashPrefix  = "$1$"
+)
+
+// isPasswordHash returns true if the password is a hash in a supported format.
+func isPasswordHash(password string) bool {
+	return isBcryptHash(password) || isMD5CryptHash(password) || strings.HasPrefix(password, shaHashPrefix)
+}
+
+// isBcryptHash returns true if the password is a bcrypt hash.
+func isBcryptHash(password string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(password, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMD5CryptHash returns true if the password is an Apache apr1 or MD5-crypt hash.
+func isMD5CryptHash(password string) bool {
+	return strings.HasPrefix(password, apr1HashPrefix) || strings.HasPrefix(password, md5HashPrefix)
+}
+
+// checkPasswordHash returns an error if the hash is malformed.
+func checkPasswordHash(hash string) error {
+	switch {
+	case isBcryptHash(hash):
+		_, err := bcrypt.Cost([]byte(hash))
+		return err
+	case isMD5CryptHash(hash):
+		if _, _, ok := splitMD5CryptHash(hash); !ok {
+			return errors.New("malformed MD5-crypt password hash")
+		}
+		return nil
+	case strings.HasPrefix(hash, shaHashPrefix):
+		if b, err := base64.StdEncoding.DecodeString(hash[len(shaHashPrefix):]); err != nil || len(b) != sha1.Size {
+			return errors.New("malformed SHA-1 password hash")
+		}
+		return nil
+	case strings.HasPrefix(hash, "$"):
+		return errors.New("unsupported password hash format")
+	}
+	return nil
+}
+
+// verifyPasswordHash verifies the plaintext password against the hash.
+// If the hash is not in a supported format, it is compared with the password as a plaintext password.
+func verifyPasswordHash(hash string, password string) (bool, error) {
+	switch {
+	case isBcryptHash(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case isMD5CryptHash(hash):
+		magic, salt, ok := splitMD5CryptHash(hash)
+		if !ok {
+			return false, errors.New("malformed MD5-crypt password hash")
+		}
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(md5Crypt(password, salt, magic))) == 1, nil
+	case strings.HasPrefix(hash, shaHashPrefix):
+		sum := sha1.Sum([]byte(password)) // nolint: gosec
+		expected := shaHashPrefix + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1, nil
+}
+
+// splitMD5CryptHash returns the magic and the salt of the MD5-crypt hash.
+func splitMD5CryptHash(hash string) (string, string, bool) {
+	magic := md5HashPrefix
+	if strings.HasPrefix(hash, apr1HashPrefix) {
+		magic = apr1HashPrefix
+	}
+	salt, sum, ok := strings.Cut(hash[len(magic):], "$")
+	if !ok || len(salt) == 0 || 8 < len(salt) || len(sum) != 22 {
+		return "", "", false
+	}
+	return magic, salt, true
+}
+
+// md5Crypt returns the MD5-crypt hash of the password as defined by FreeBSD and used by Apache with the apr1 magic.
+func md5Crypt(password string, salt string, magic string) string {
+	pw := []byte(password)
+	d := md5.New() // nolint: gosec
+	d.Write(pw)
+	d.Write([]byte(magic))
+	d.Write([]byte(salt))
+
+	alt := md5.New() // nolint: gosec
+	alt.Write(pw)
+	alt.Write([]byte(salt))
+	alt.Write(pw)
+	mixin := alt.Sum(nil)
+	for n := len(pw); n > 0; n -= md5.Size {
+		d.Write(mixin[:min(n, md5.Size)])
+	}
+	for n := len(pw); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			d.Write([]byte{0})
+		} else {
+			d.Write(pw[:1])
+		}
+	}
+	final := d.Sum(nil)
+
+	for n := range 1000 {
+		round := md5.New() // nolint: gosec
+		if n&1 != 0 {
+			round.Write(pw)
+		} else {
+			round.Write(final)
+		}
+		if n%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if n%7 != 0 {
+			round.Write(pw)
+		}
+		if n&1 != 0 {
+			round.Write(final)
+		} else {
+			round.Write(pw)
+		}
+		final = round.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	sum := make([]byte, 0, 22)
+	encode := func(a byte, b byte, c byte, n int) {
+		v := uint(a)<<16 | uint(b)<<8 | uint(c)
+		for ; 0 < n; n-- {
+			sum = append(sum, itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	encode(final[0], final[6], final[12], 4)
+	encode(final[1], final[7], final[13], 4)
+	encode(final[2], final[8], final[14], 4)
+	encode(final[3], final[9], final[15], 4)
+	encode(final[4], final[10], final[5], 4)
+	encode(0, 0, final[11], 2)
+	return magic + salt + "$" + string(sum)
+}
+
+// passwordString returns the password as a string.
+func passwordString(password any) (string, bool) {
+	switch p := password.(type) {
+	case string:
+		return p, true
+	case []byte:
+		return string(p), true
+	}
+	return "", false
+}
+
+// isPlainQuery returns true if the password of the query is not encrypted by the mechanism.
+func isPlainQuery(q Query) bool {
+	encryptFunc := q.EncryptFunc()
+	return encryptFunc == nil || reflect.ValueOf(encryptFunc).Pointer() == reflect.ValueOf(auth.PlainEncrypt).Pointer()
+}
+
+// passwordHashCredential is the interface for a credential whose password must be a hash.
+type passwordHashCredential interface {
+	// passwordHashRequired returns true if the password must be verified as a hash and never as a plaintext password.
+	passwordHashRequired() bool
+}
+
+type passwordHashAuthenticator struct {
+	credStore CredentialStore
+}
+
+// NewPasswordHashAuthenticator returns a new credential authenticator which verifies the plaintext password
+// of the query against the password of the credential looked up by the credential store. The password of
+// the credential can be a bcrypt ($2a$, $2b$ or $2y$), SHA-1 ({SHA}), Apache MD5 ($apr1$) or MD5-crypt ($1$) hash,
+// or a plaintext password.
+// Queries of mechanisms which encrypt the password, such as SCRAM, are verified by the default credential authenticator
+// if the password of the credential is a plaintext password, and are rejected if it is a hash.
+func NewPasswordHashAuthenticator() DefaultCredentialAuthenticator {
+	return &passwordHashAuthenticator{
+		credStore: nil,
+	}
+}
+
+// SetCredentialStore sets the credential store.
+func (ca *passwordHashAuthenticator) SetCredentialStore(credStore CredentialStore) {
+	ca.credStore = credStore
+}
+
+// CredentialStore returns the credential store.
+func (ca *passwordHashAuthenticator) CredentialStore() CredentialStore {
+	return ca.credStore
+}
+
+// VerifyCredential verifies the client credential.
+func (ca *passwordHashAuthenticator) VerifyCredential(conn Conn, q Query) (bool, error) {
+	_, ok, err := ca.VerifyCredentialPrincipal(conn, q)
+	return ok, err
+}
+
+// VerifyCredentialPrincipal verifies the client credential and returns the authenticated principal.
+// The group of the principal is taken from the looked-up credential rather than the client query.
+func (ca *passwordHashAuthenticator) VerifyCredentialPrincipal(conn Conn, q Query) (Principal, bool, error) {
+	if ca.credStore == nil {
+		return nil, false, ErrNoCredentialStore
+	}
+	cred, ok, err := ca.credStore.LookupCredential(q)
+	if !ok {
+		return nil, false, err
+	}
+	ok, err = verifyPasswordCredential(conn, q, cred)
+	if !ok {
+		return nil, false, err
+	}
+	return newCredentialPrincipal(q, cred), true, err
+}
+
+// verifyPasswordCredential verifies the client credential against the looked-up credential.
+func verifyPasswordCredential(conn Conn, q Query, cred Credential) (bool, error) {
+	hash, ok := passwordString(cred.Password())
+	if !ok {
+		return false, errors.New("credential password is not a string")
+	}
+	if hc, ok := cred.(passwordHashCredential); ok && hc.passwordHashRequired() && !isPasswordHash(hash) {
+		return false, errors.New("credential password is not a supported password hash")
+	}
+	if !isPlainQuery(q) {
+		if isPasswordHash(hash) {
+			return false, errors.New("password hash cannot be verified by an encrypted password")
+		}
+		return verifyCredentialDefault(conn, q, cred)
+	}
+	password, ok := passwordString(q.Password())
+	if !ok {
+		return false, nil
+	}
+	return verifyPasswordHash(hash, password)
+}
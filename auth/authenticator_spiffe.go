@@ -0,0 +1,166 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+)
+
+const (
+	// PrincipalSPIFFEID is the principal attribute name of the SPIFFE ID.
+	PrincipalSPIFFEID = "spiffe_id"
+	// PrincipalSPIFFETrustDomain is the principal attribute name of the SPIFFE trust domain.
+	PrincipalSPIFFETrustDomain = "spiffe_trust_domain"
+	// PrincipalSPIFFEPath is the principal attribute name of the SPIFFE ID path.
+	PrincipalSPIFFEPath = "spiffe_path"
+)
+
+type spiffeAuthenticator struct {
+	trustDomains []string
+	ids          []string
+	pathRegexp   []*regexp.Regexp
+}
+
+// SPIFFEAuthenticatorOption is a function to set the SPIFFE authenticator options.
+type SPIFFEAuthenticatorOption = func(*spiffeAuthenticator) error
+
+// WithSPIFFETrustDomain sets the trust domains which are allowed by the SPIFFE authenticator.
+func WithSPIFFETrustDomain(trustDomains ...string) SPIFFEAuthenticatorOption {
+	return func(sa *spiffeAuthenticator) error {
+		for _, td := range trustDomains {
+			id, err := tls.ParseSPIFFEID("spiffe://" + td)
+			if err != nil {
+				return err
+			}
+			sa.trustDomains = append(sa.trustDomains, id.TrustDomain)
+		}
+		return nil
+	}
+}
+
+// WithSPIFFEID sets the SPIFFE IDs which are allowed by the SPIFFE authenticator.
+func WithSPIFFEID(ids ...string) SPIFFEAuthenticatorOption {
+	return func(sa *spiffeAuthenticator) error {
+		for _, id := range ids {
+			sid, err := tls.ParseSPIFFEID(id)
+			if err != nil {
+				return err
+			}
+			sa.ids = append(sa.ids, sid.String())
+		}
+		return nil
+	}
+}
+
+// WithSPIFFEPathRegexp sets the path regular expressions which are allowed by the SPIFFE authenticator.
+// The regular expressions are evaluated against the path of the SPIFFE ID such as "/ns/default/sa/app".
+func WithSPIFFEPathRegexp(regexps ...string) SPIFFEAuthenticatorOption {
+	return func(sa *spiffeAuthenticator) error {
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		sa.pathRegexp = append(sa.pathRegexp, r...)
+		return nil
+	}
+}
+
+// NewSPIFFEAuthenticator returns a new certificate authenticator which authenticates clients by X.509-SVIDs.
+// A client is admitted if the trust domain of the SPIFFE ID is allowed and, when any SPIFFE ID or path rule is specified,
+// the SPIFFE ID matches any of the rules. The principal username is the SPIFFE ID, and the group is the trust domain.
+func NewSPIFFEAuthenticator(opts ...SPIFFEAuthenticatorOption) (CertificateAuthenticator, error) {
+	sa := &spiffeAuthenticator{
+		trustDomains: []string{},
+		ids:          []string{},
+		pathRegexp:   []*regexp.Regexp{},
+	}
+	for _, opt := range opts {
+		if err := opt(sa); err != nil {
+			return nil, err
+		}
+	}
+	return sa, nil
+}
+
+// VerifyCertificate verifies the client certificate.
+func (sa *spiffeAuthenticator) VerifyCertificate(conn tls.Conn) (bool, error) {
+	_, ok, err := sa.VerifyCertificatePrincipal(conn)
+	return ok, err
+}
+
+// VerifyCertificatePrincipal verifies the client certificate and returns the principal of the SPIFFE ID.
+// A client certificate which does not have exactly one valid SPIFFE ID is rejected without an error.
+func (sa *spiffeAuthenticator) VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error) {
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, false, nil
+	}
+	leaf := state.PeerCertificates[0]
+	if leaf.IsCA {
+		return nil, false, nil
+	}
+	id, err := tls.SPIFFEIDFromCertificate(leaf)
+	if err != nil {
+		return nil, false, nil //nolint: nilerr
+	}
+	if !slices.Contains(sa.trustDomains, id.TrustDomain) {
+		return nil, false, nil
+	}
+	if !sa.matchID(id) {
+		return nil, false, nil
+	}
+	p := newCertificatePrincipal(leaf,
+		WithPrincipalUsername(id.String()),
+		WithPrincipalGroup(id.TrustDomain),
+		WithPrincipalAttribute(PrincipalSPIFFEID, id.String()),
+		WithPrincipalAttribute(PrincipalSPIFFETrustDomain, id.TrustDomain),
+		WithPrincipalAttribute(PrincipalSPIFFEPath, id.Path),
+	)
+	return p, true, nil
+}
+
+// VerifyCertificateQuery verifies the client certificate and checks that the SPIFFE ID matches the queried username
+// and the trust domain matches the queried group exactly.
+func (sa *spiffeAuthenticator) VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error) {
+	p, ok, err := sa.VerifyCertificatePrincipal(conn)
+	if !ok {
+		return nil, false, err
+	}
+	if p.Username() != q.Username() {
+		return nil, false, nil
+	}
+	if p.Group() != q.Group() {
+		return nil, false, nil
+	}
+	return p, true, nil
+}
+
+func (sa *spiffeAuthenticator) matchID(id *tls.SPIFFEID) bool {
+	if len(sa.ids) == 0 && len(sa.pathRegexp) == 0 {
+		return true
+	}
+	if slices.Contains(sa.ids, id.String()) {
+		return true
+	}
+	for _, re := range sa.pathRegexp {
+		if re.MatchString(id.Path) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,28 +15,168 @@
 package auth
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
 	"regexp"
+	"slices"
 
 	"github.com/cybergarage/go-authenticator/auth/tls"
 )
 
 type certificateAuthenticator struct {
-	commonNameRegexp []*regexp.Regexp
+	commonNameRegexp      []*regexp.Regexp
+	dnsNameRegexp         []*regexp.Regexp
+	emailAddressRegexp    []*regexp.Regexp
+	uriRegexp             []*regexp.Regexp
+	ipAddressNets         []*net.IPNet
+	leafOnly              bool
+	issuerCNRegexp        []*regexp.Regexp
+	issuerCerts           []*x509.Certificate
+	mappings              []*certificateMapping
+	subjectDNMatchers     []dnMatcher
+	issuerDNMatchers      []dnMatcher
+	certFingerprints      []tls.Fingerprint
+	publicKeyFingerprints []tls.Fingerprint
+	revocationCheckers    []tls.RevocationChecker
+	policy                *certificatePolicy
 }
 
 // CertificateAuthenticatorOption is a function to set the certificate authenticator options.
 type CertificateAuthenticatorOption = func(*certificateAuthenticator) error
 
+func compileRegexps(regexps ...string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(regexps))
+	for _, re := range regexps {
+		r, err := regexp.Compile(re)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
 // WithCommonNameRegexp sets the common name regular expressions to the certificate authenticator.
 func WithCommonNameRegexp(regexps ...string) CertificateAuthenticatorOption {
 	return func(ca *certificateAuthenticator) error {
-		for _, re := range regexps {
-			r, err := regexp.Compile(re)
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		ca.commonNameRegexp = append(ca.commonNameRegexp, r...)
+		return nil
+	}
+}
+
+// WithDNSNameRegexp sets the DNS name regular expressions to the certificate authenticator.
+// The regular expressions are evaluated against the DNS names in the subject alternative name extension.
+func WithDNSNameRegexp(regexps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		ca.dnsNameRegexp = append(ca.dnsNameRegexp, r...)
+		return nil
+	}
+}
+
+// WithEmailAddressRegexp sets the email address regular expressions to the certificate authenticator.
+// The regular expressions are evaluated against the email addresses in the subject alternative name extension.
+func WithEmailAddressRegexp(regexps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		ca.emailAddressRegexp = append(ca.emailAddressRegexp, r...)
+		return nil
+	}
+}
+
+// WithURIRegexp sets the URI regular expressions to the certificate authenticator.
+// The regular expressions are evaluated against the URIs in the subject alternative name extension.
+func WithURIRegexp(regexps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		ca.uriRegexp = append(ca.uriRegexp, r...)
+		return nil
+	}
+}
+
+// WithIPAddressCIDR sets the IP address ranges in CIDR notation to the certificate authenticator.
+// The ranges are evaluated against the IP addresses in the subject alternative name extension.
+func WithIPAddressCIDR(cidrs ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		for _, cidr := range cidrs {
+			_, ipNet, err := net.ParseCIDR(cidr)
 			if err != nil {
 				return err
 			}
-			ca.commonNameRegexp = append(ca.commonNameRegexp, r)
+			ca.ipAddressNets = append(ca.ipAddressNets, ipNet)
+		}
+		return nil
+	}
+}
+
+// WithLeafCertificateOnly sets whether the identity rules are evaluated against the leaf certificate only.
+// It is enabled by default. If disabled, the identity rules are evaluated against every certificate
+// presented by the client, and a client is admitted if any certificate in the chain matches.
+func WithLeafCertificateOnly(enabled bool) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.leafOnly = enabled
+		return nil
+	}
+}
+
+// WithIssuerCommonNameRegexp sets the issuer common name regular expressions to the certificate authenticator.
+// A client is admitted only if an intermediate or root certificate in a verified chain matches any of the regular expressions.
+func WithIssuerCommonNameRegexp(regexps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		r, err := compileRegexps(regexps...)
+		if err != nil {
+			return err
+		}
+		ca.issuerCNRegexp = append(ca.issuerCNRegexp, r...)
+		return nil
+	}
+}
+
+// WithIssuerCertificates sets the PEM encoded issuer certificates to the certificate authenticator.
+// A client is admitted only if a verified chain contains any of the certificates as an intermediate or root certificate.
+func WithIssuerCertificates(certs ...[]byte) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		nCerts := len(ca.issuerCerts)
+		for _, cert := range certs {
+			for block, rest := pem.Decode(cert); block != nil; block, rest = pem.Decode(rest) {
+				if block.Type != "CERTIFICATE" {
+					continue
+				}
+				c, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					return err
+				}
+				ca.issuerCerts = append(ca.issuerCerts, c)
+			}
 		}
+		if len(ca.issuerCerts) == nCerts {
+			return errors.New("no issuer certificates found")
+		}
+		return nil
+	}
+}
+
+// WithRevocationChecker sets the revocation checkers to the certificate authenticator such as CertConfig.RevocationChecker().
+// The verified chains are checked, and a client whose certificate is revoked is rejected with an error wrapping tls.ErrRevoked.
+func WithRevocationChecker(checkers ...tls.RevocationChecker) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.revocationCheckers = append(ca.revocationCheckers, checkers...)
 		return nil
 	}
 }
@@ -44,7 +184,21 @@ func WithCommonNameRegexp(regexps ...string) CertificateAuthenticatorOption {
 // NewCertificateAuthenticator returns a new certificate authenticator with the options.
 func NewCertificateAuthenticator(opts ...CertificateAuthenticatorOption) (CertificateAuthenticator, error) {
 	ca := &certificateAuthenticator{
-		commonNameRegexp: []*regexp.Regexp{},
+		commonNameRegexp:      []*regexp.Regexp{},
+		dnsNameRegexp:         []*regexp.Regexp{},
+		emailAddressRegexp:    []*regexp.Regexp{},
+		uriRegexp:             []*regexp.Regexp{},
+		ipAddressNets:         []*net.IPNet{},
+		leafOnly:              true,
+		issuerCNRegexp:        []*regexp.Regexp{},
+		issuerCerts:           []*x509.Certificate{},
+		mappings:              []*certificateMapping{},
+		subjectDNMatchers:     []dnMatcher{},
+		issuerDNMatchers:      []dnMatcher{},
+		certFingerprints:      []tls.Fingerprint{},
+		publicKeyFingerprints: []tls.Fingerprint{},
+		revocationCheckers:    []tls.RevocationChecker{},
+		policy:                newCertificatePolicy(),
 	}
 	for _, opt := range opts {
 		if err := opt(ca); err != nil {
@@ -56,13 +210,202 @@ func NewCertificateAuthenticator(opts ...CertificateAuthenticatorOption) (Certif
 
 // VerifyCertificate verifies the client certificate.
 func (ca *certificateAuthenticator) VerifyCertificate(conn tls.Conn) (bool, error) {
+	_, ok, err := ca.VerifyCertificatePrincipal(conn)
+	return ok, err
+}
+
+// VerifyCertificatePrincipal verifies the client certificate and returns the principal of the leaf certificate.
+// The identity rules are evaluated against the leaf certificate unless WithLeafCertificateOnly(false) is specified,
+// and the issuer rules are evaluated against the intermediate and root certificates of the verified chains.
+// A leaf certificate which violates the certificate policy is rejected with an error wrapping ErrCertificatePolicy.
+// A leaf certificate pinned by a fingerprint is admitted without evaluating the issuer and identity rules.
+// If mapping rules are specified, the principal is resolved by the first matching rule.
+// An authenticator with issuer rules and no identity rules admits every certificate issued by the matching issuers,
+// and an authenticator with none of the identity, issuer, mapping and fingerprint rules rejects every certificate.
+func (ca *certificateAuthenticator) VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error) {
+	return ca.verifyCertificate(conn, nil)
+}
+
+// VerifyCertificateQuery verifies the client certificate and checks that the principal matches the queried username and group.
+func (ca *certificateAuthenticator) VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error) {
+	return ca.verifyCertificate(conn, q)
+}
+
+func (ca *certificateAuthenticator) verifyCertificate(conn tls.Conn, q Query) (Principal, bool, error) {
 	state := conn.ConnectionState()
-	for _, cert := range state.PeerCertificates {
-		for _, re := range ca.commonNameRegexp {
-			if re.MatchString(cert.Subject.CommonName) {
-				return true, nil
+	if len(state.PeerCertificates) == 0 {
+		return nil, false, nil
+	}
+	if err := ca.checkRevocation(state.VerifiedChains); err != nil {
+		return nil, false, err
+	}
+	leaf := state.PeerCertificates[0]
+	if err := ca.policy.Check(leaf); err != nil {
+		return nil, false, err
+	}
+	if ca.matchFingerprint(leaf) {
+		p, ok := ca.mapPrincipal(leaf, q)
+		return p, ok, nil
+	}
+	if !ca.matchIssuer(state.VerifiedChains) {
+		return nil, false, nil
+	}
+	if ca.hasIdentityRules() {
+		certs := state.PeerCertificates[:1]
+		if !ca.leafOnly {
+			certs = state.PeerCertificates
+		}
+		if !slices.ContainsFunc(certs, ca.matchIdentity) {
+			return nil, false, nil
+		}
+	} else if len(ca.mappings) == 0 && !ca.hasIssuerRules() {
+		return nil, false, nil
+	}
+	p, ok := ca.mapPrincipal(leaf, q)
+	return p, ok, nil
+}
+
+// hasIssuerRules returns true if any issuer rule is specified.
+func (ca *certificateAuthenticator) hasIssuerRules() bool {
+	return 0 < len(ca.issuerCNRegexp) ||
+		0 < len(ca.issuerCerts) ||
+		0 < len(ca.issuerDNMatchers)
+}
+
+// hasIdentityRules returns true if any identity rule is specified.
+func (ca *certificateAuthenticator) hasIdentityRules() bool {
+	return 0 < len(ca.commonNameRegexp) ||
+		0 < len(ca.dnsNameRegexp) ||
+		0 < len(ca.emailAddressRegexp) ||
+		0 < len(ca.uriRegexp) ||
+		0 < len(ca.ipAddressNets) ||
+		0 < len(ca.subjectDNMatchers)
+}
+
+// matchIdentity returns true if the specified certificate matches any of the identity rules.
+func (ca *certificateAuthenticator) matchIdentity(cert *x509.Certificate) bool {
+	return ca.matchCommonName(cert) || ca.matchSubjectAltName(cert) || ca.matchSubjectDN(cert)
+}
+
+// matchSubjectDN returns true if the subject distinguished name of the specified certificate matches any of the subject rules.
+func (ca *certificateAuthenticator) matchSubjectDN(cert *x509.Certificate) bool {
+	for _, m := range ca.subjectDNMatchers {
+		if m(cert.Subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ca *certificateAuthenticator) matchCommonName(cert *x509.Certificate) bool {
+	for _, re := range ca.commonNameRegexp {
+		if re.MatchString(cert.Subject.CommonName) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyString(regexps []*regexp.Regexp, values []string) bool {
+	for _, re := range regexps {
+		for _, value := range values {
+			if re.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSubjectAltName returns true if the subject alternative names of the specified certificate match any of the rules.
+func (ca *certificateAuthenticator) matchSubjectAltName(cert *x509.Certificate) bool {
+	if matchAnyString(ca.dnsNameRegexp, cert.DNSNames) {
+		return true
+	}
+	if matchAnyString(ca.emailAddressRegexp, cert.EmailAddresses) {
+		return true
+	}
+	uris := make([]string, len(cert.URIs))
+	for n, uri := range cert.URIs {
+		uris[n] = uri.String()
+	}
+	if matchAnyString(ca.uriRegexp, uris) {
+		return true
+	}
+	for _, ipNet := range ca.ipAddressNets {
+		for _, ip := range cert.IPAddresses {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchIssuer returns true if any of the verified chains satisfies all of the issuer rules.
+// The peer certificates are not used because the client can present arbitrary certificates in addition to the leaf.
+func (ca *certificateAuthenticator) matchIssuer(chains [][]*x509.Certificate) bool {
+	if !ca.hasIssuerRules() {
+		return true
+	}
+	for _, chain := range chains {
+		if len(chain) < 2 {
+			continue
+		}
+		issuers := chain[1:]
+		if ca.matchIssuerCommonName(issuers) && ca.matchIssuerCertificate(issuers) && ca.matchIssuerDN(chain[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ca *certificateAuthenticator) matchIssuerCommonName(issuers []*x509.Certificate) bool {
+	if len(ca.issuerCNRegexp) == 0 {
+		return true
+	}
+	for _, issuer := range issuers {
+		for _, re := range ca.issuerCNRegexp {
+			if re.MatchString(issuer.Subject.CommonName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ca *certificateAuthenticator) matchIssuerCertificate(issuers []*x509.Certificate) bool {
+	if len(ca.issuerCerts) == 0 {
+		return true
+	}
+	for _, issuer := range issuers {
+		for _, cert := range ca.issuerCerts {
+			if bytes.Equal(issuer.Raw, cert.Raw) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchIssuerDN returns true if the issuer distinguished name of the specified certificate matches all of the issuer rules.
+func (ca *certificateAuthenticator) matchIssuerDN(cert *x509.Certificate) bool {
+	for _, m := range ca.issuerDNMatchers {
+		if !m(cert.Issuer) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRevocation checks the revocation status of the verified chains.
+func (ca *certificateAuthenticator) checkRevocation(chains [][]*x509.Certificate) error {
+	for _, checker := range ca.revocationCheckers {
+		for _, chain := range chains {
+			if err := checker.CheckRevocation(chain); err != nil {
+				return err
 			}
 		}
 	}
-	return false, nil
+	return nil
 }
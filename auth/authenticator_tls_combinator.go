@@ -0,0 +1,251 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+)
+
+// CertificateDecision represents the decision of a certificate rule.
+type CertificateDecision int
+
+const (
+	// CertificateAllow admits the certificate.
+	CertificateAllow CertificateDecision = iota
+	// CertificateDeny rejects the certificate.
+	CertificateDeny
+)
+
+// String returns the string representation of the decision.
+func (d CertificateDecision) String() string {
+	switch d {
+	case CertificateAllow:
+		return "allow"
+	case CertificateDeny:
+		return "deny"
+	}
+	return "unknown"
+}
+
+// CertificateRule represents a rule of FirstMatch which applies the decision if the authenticator admits the certificate.
+type CertificateRule struct {
+	Authenticator CertificateAuthenticator
+	Decision      CertificateDecision
+}
+
+// AllowCertificate returns a rule which admits the certificate if the authenticator admits it.
+func AllowCertificate(auth CertificateAuthenticator) CertificateRule {
+	return CertificateRule{Authenticator: auth, Decision: CertificateAllow}
+}
+
+// DenyCertificate returns a rule which rejects the certificate if the authenticator admits it.
+func DenyCertificate(auth CertificateAuthenticator) CertificateRule {
+	return CertificateRule{Authenticator: auth, Decision: CertificateDeny}
+}
+
+// isCertificateMismatch returns true if the error only means that the certificate does not match the authenticator,
+// such as a certificate policy violation, rather than a failure such as a revocation check error.
+func isCertificateMismatch(err error) bool {
+	return errors.Is(err, ErrCertificatePolicy)
+}
+
+// verifyCertificateWith verifies the certificate by the authenticator. The principal is nil unless the authenticator resolves it.
+// A mismatch error is treated as a rejection without an error.
+func verifyCertificateWith(auth CertificateAuthenticator, conn tls.Conn, q Query) (Principal, bool, error) {
+	var p Principal
+	var ok bool
+	var err error
+	if pa, isPrincipal := auth.(CertificatePrincipalAuthenticator); isPrincipal {
+		if q == nil {
+			p, ok, err = pa.VerifyCertificatePrincipal(conn)
+		} else {
+			p, ok, err = pa.VerifyCertificateQuery(conn, q)
+		}
+	} else {
+		ok, err = auth.VerifyCertificate(conn)
+	}
+	if isCertificateMismatch(err) {
+		return nil, false, nil
+	}
+	return p, ok, err
+}
+
+// matchCertificate verifies the certificate by the authenticator without resolving the principal.
+// A mismatch error is treated as a rejection without an error.
+func matchCertificate(auth CertificateAuthenticator, conn tls.Conn) (bool, error) {
+	ok, err := auth.VerifyCertificate(conn)
+	if isCertificateMismatch(err) {
+		return false, nil
+	}
+	return ok, err
+}
+
+// newLeafPrincipal returns the principal of the leaf certificate, and checks that the username matches the query.
+func newLeafPrincipal(conn tls.Conn, q Query) (Principal, bool) {
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, false
+	}
+	p := newCertificatePrincipal(state.PeerCertificates[0])
+	if q != nil && p.Username() != q.Username() {
+		return nil, false
+	}
+	return p, true
+}
+
+// certificateCombinator represents a combinator of certificate authenticators.
+type certificateCombinator struct {
+	verify func(conn tls.Conn, q Query) (Principal, bool, error)
+}
+
+// VerifyCertificate verifies the client certificate.
+func (c *certificateCombinator) VerifyCertificate(conn tls.Conn) (bool, error) {
+	_, ok, err := c.verify(conn, nil)
+	return ok, err
+}
+
+// VerifyCertificatePrincipal verifies the client certificate and returns the authenticated principal.
+func (c *certificateCombinator) VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error) {
+	return c.verify(conn, nil)
+}
+
+// VerifyCertificateQuery verifies the client certificate and checks that the principal matches the queried username and group.
+func (c *certificateCombinator) VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error) {
+	return c.verify(conn, q)
+}
+
+// AllOf returns a certificate authenticator which admits the certificate only if all of the authenticators admit it.
+// The principal and the query are resolved by the first authenticator which implements CertificatePrincipalAuthenticator,
+// or by the leaf certificate if there is no such authenticator. AllOf with no authenticators rejects every certificate.
+// A certificate policy violation is treated as a rejection, and any other error from an authenticator stops the evaluation and is returned.
+func AllOf(auths ...CertificateAuthenticator) CertificatePrincipalAuthenticator {
+	return &certificateCombinator{
+		verify: func(conn tls.Conn, q Query) (Principal, bool, error) {
+			if len(auths) == 0 {
+				return nil, false, nil
+			}
+			var principal Principal
+			resolved := false
+			for _, auth := range auths {
+				_, isPrincipal := auth.(CertificatePrincipalAuthenticator)
+				if resolved || !isPrincipal {
+					ok, err := matchCertificate(auth, conn)
+					if err != nil || !ok {
+						return nil, false, err
+					}
+					continue
+				}
+				p, ok, err := verifyCertificateWith(auth, conn, q)
+				if err != nil || !ok {
+					return nil, false, err
+				}
+				principal = p
+				resolved = true
+			}
+			if principal == nil {
+				p, ok := newLeafPrincipal(conn, q)
+				return p, ok, nil
+			}
+			return principal, true, nil
+		},
+	}
+}
+
+// AnyOf returns a certificate authenticator which admits the certificate if any of the authenticators admits it.
+// The authenticators are evaluated in order, and the principal is resolved by the first authenticator which admits the certificate.
+// A certificate policy violation is treated as a rejection, and any other error from an authenticator stops the evaluation and is returned.
+func AnyOf(auths ...CertificateAuthenticator) CertificatePrincipalAuthenticator {
+	return &certificateCombinator{
+		verify: func(conn tls.Conn, q Query) (Principal, bool, error) {
+			for _, auth := range auths {
+				p, ok, err := verifyCertificateWith(auth, conn, q)
+				if err != nil {
+					return nil, false, err
+				}
+				if !ok {
+					continue
+				}
+				if p == nil {
+					if p, ok = newLeafPrincipal(conn, q); !ok {
+						continue
+					}
+				}
+				return p, true, nil
+			}
+			return nil, false, nil
+		},
+	}
+}
+
+// notCertificateAuthenticator represents a certificate authenticator which negates the authenticator.
+type notCertificateAuthenticator struct {
+	auth CertificateAuthenticator
+}
+
+// Not returns a certificate authenticator which admits the certificate only if the authenticator rejects it without an error.
+// A certificate policy violation is treated as a rejection.
+// A connection without client certificates is always rejected. Not is intended to be combined with AllOf, such as a denylist.
+func Not(auth CertificateAuthenticator) CertificateAuthenticator {
+	return &notCertificateAuthenticator{
+		auth: auth,
+	}
+}
+
+// VerifyCertificate verifies the client certificate.
+func (na *notCertificateAuthenticator) VerifyCertificate(conn tls.Conn) (bool, error) {
+	if len(conn.ConnectionState().PeerCertificates) == 0 {
+		return false, nil
+	}
+	ok, err := matchCertificate(na.auth, conn)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+// FirstMatch returns a certificate authenticator which applies the decision of the first rule whose authenticator admits the certificate.
+// The principal is resolved by the authenticator of the matched allow rule. If no rule matches, the certificate is rejected.
+// A certificate policy violation is treated as a rejection, and any other error from an authenticator stops the evaluation and is returned.
+func FirstMatch(rules ...CertificateRule) CertificatePrincipalAuthenticator {
+	return &certificateCombinator{
+		verify: func(conn tls.Conn, q Query) (Principal, bool, error) {
+			for _, rule := range rules {
+				if rule.Decision == CertificateDeny {
+					ok, err := matchCertificate(rule.Authenticator, conn)
+					if err != nil || ok {
+						return nil, false, err
+					}
+					continue
+				}
+				p, ok, err := verifyCertificateWith(rule.Authenticator, conn, q)
+				if err != nil {
+					return nil, false, err
+				}
+				if !ok {
+					continue
+				}
+				if p == nil {
+					if p, ok = newLeafPrincipal(conn, q); !ok {
+						continue
+					}
+				}
+				return p, true, nil
+			}
+			return nil, false, nil
+		},
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509/pkix"
+	"fmt"
+	"regexp"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+)
+
+// dnMatcher represents a distinguished name matcher.
+type dnMatcher func(name pkix.Name) bool
+
+// newDNMatcher returns a matcher which matches a distinguished name exactly equal to the specified RFC 4514 string.
+func newDNMatcher(dn string) (dnMatcher, error) {
+	rdns, err := tls.ParseDistinguishedName(dn)
+	if err != nil {
+		return nil, err
+	}
+	expected := []pkix.AttributeTypeAndValue{}
+	for _, rdn := range rdns {
+		expected = append(expected, rdn...)
+	}
+	return func(name pkix.Name) bool {
+		if len(name.Names) != len(expected) {
+			return false
+		}
+		for n, atv := range name.Names {
+			if !atv.Type.Equal(expected[n].Type) || fmt.Sprint(atv.Value) != expected[n].Value {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// newDNAttributesMatcher returns a matcher which matches a distinguished name containing all attributes of the specified RFC 4514 string.
+func newDNAttributesMatcher(dn string) (dnMatcher, error) {
+	rdns, err := tls.ParseDistinguishedName(dn)
+	if err != nil {
+		return nil, err
+	}
+	return func(name pkix.Name) bool {
+		for _, rdn := range rdns {
+			for _, expected := range rdn {
+				if !containsAttribute(name, expected) {
+					return false
+				}
+			}
+		}
+		return true
+	}, nil
+}
+
+// newDNRegexpMatcher returns a matcher which matches the RFC 4514 string form of a distinguished name with the regular expression.
+func newDNRegexpMatcher(re string) (dnMatcher, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+	return func(name pkix.Name) bool {
+		return r.MatchString(name.String())
+	}, nil
+}
+
+// newDNAttributeRegexpMatcher returns a matcher which matches a distinguished name having the attribute whose value matches the regular expression.
+func newDNAttributeRegexpMatcher(attr string, re string) (dnMatcher, error) {
+	oid, err := tls.ParseAttributeType(attr)
+	if err != nil {
+		return nil, err
+	}
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+	return func(name pkix.Name) bool {
+		for _, atv := range name.Names {
+			if atv.Type.Equal(oid) && r.MatchString(fmt.Sprint(atv.Value)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func containsAttribute(name pkix.Name, expected pkix.AttributeTypeAndValue) bool {
+	for _, atv := range name.Names {
+		if atv.Type.Equal(expected.Type) && fmt.Sprint(atv.Value) == expected.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func withSubjectDNMatcher(newMatcher func() (dnMatcher, error)) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		m, err := newMatcher()
+		if err != nil {
+			return err
+		}
+		ca.subjectDNMatchers = append(ca.subjectDNMatchers, m)
+		return nil
+	}
+}
+
+func withIssuerDNMatcher(newMatcher func() (dnMatcher, error)) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		m, err := newMatcher()
+		if err != nil {
+			return err
+		}
+		ca.issuerDNMatchers = append(ca.issuerDNMatchers, m)
+		return nil
+	}
+}
+
+// WithSubjectDN adds an identity rule which matches a subject distinguished name exactly equal to the RFC 4514 string such as "CN=app,OU=payments,O=Corp".
+func WithSubjectDN(dn string) CertificateAuthenticatorOption {
+	return withSubjectDNMatcher(func() (dnMatcher, error) { return newDNMatcher(dn) })
+}
+
+// WithSubjectDNRegexp adds an identity rule which matches the RFC 4514 string form of the subject distinguished name with the regular expression.
+func WithSubjectDNRegexp(re string) CertificateAuthenticatorOption {
+	return withSubjectDNMatcher(func() (dnMatcher, error) { return newDNRegexpMatcher(re) })
+}
+
+// WithSubjectAttributes adds an identity rule which matches a subject distinguished name containing all attributes of the RFC 4514 string such as "OU=payments,O=Corp".
+func WithSubjectAttributes(dn string) CertificateAuthenticatorOption {
+	return withSubjectDNMatcher(func() (dnMatcher, error) { return newDNAttributesMatcher(dn) })
+}
+
+// WithSubjectAttributeRegexp adds an identity rule which matches a subject distinguished name having the attribute such as "OU" or "serialNumber"
+// whose value matches the regular expression.
+func WithSubjectAttributeRegexp(attr string, re string) CertificateAuthenticatorOption {
+	return withSubjectDNMatcher(func() (dnMatcher, error) { return newDNAttributeRegexpMatcher(attr, re) })
+}
+
+// WithIssuerDN adds an issuer rule which requires the issuer distinguished name of the leaf certificate to be exactly equal to the RFC 4514 string.
+// As the other issuer rules, the issuer distinguished name rules are evaluated only against the verified chains, and all of them must match.
+func WithIssuerDN(dn string) CertificateAuthenticatorOption {
+	return withIssuerDNMatcher(func() (dnMatcher, error) { return newDNMatcher(dn) })
+}
+
+// WithIssuerDNRegexp adds an issuer rule which requires the RFC 4514 string form of the issuer distinguished name of the leaf certificate to match the regular expression.
+func WithIssuerDNRegexp(re string) CertificateAuthenticatorOption {
+	return withIssuerDNMatcher(func() (dnMatcher, error) { return newDNRegexpMatcher(re) })
+}
+
+// WithIssuerAttributes adds an issuer rule which requires the issuer distinguished name of the leaf certificate to contain all attributes of the RFC 4514 string.
+func WithIssuerAttributes(dn string) CertificateAuthenticatorOption {
+	return withIssuerDNMatcher(func() (dnMatcher, error) { return newDNAttributesMatcher(dn) })
+}
+
+// WithIssuerAttributeRegexp adds an issuer rule which requires the issuer distinguished name of the leaf certificate to have the attribute
+// whose value matches the regular expression.
+func WithIssuerAttributeRegexp(attr string, re string) CertificateAuthenticatorOption {
+	return withIssuerDNMatcher(func() (dnMatcher, error) { return newDNAttributeRegexpMatcher(attr, re) })
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CertificateField represents a certificate field to which a mapping rule is applied.
+type CertificateField string
+
+const (
+	// CertificateSubject represents the subject distinguished name in RFC 2253 form such as "CN=app,O=example".
+	CertificateSubject CertificateField = "subject"
+	// CertificateCommonName represents the subject common name.
+	CertificateCommonName CertificateField = "CN"
+	// CertificateDNSName represents the DNS names in the subject alternative name extension.
+	CertificateDNSName CertificateField = "DNS"
+	// CertificateEmailAddress represents the email addresses in the subject alternative name extension.
+	CertificateEmailAddress CertificateField = "email"
+	// CertificateURI represents the URIs in the subject alternative name extension.
+	CertificateURI CertificateField = "URI"
+)
+
+// Values returns the values of the field in the specified certificate.
+func (field CertificateField) Values(cert *x509.Certificate) []string {
+	switch field {
+	case CertificateSubject:
+		return []string{cert.Subject.String()}
+	case CertificateCommonName:
+		return []string{cert.Subject.CommonName}
+	case CertificateDNSName:
+		return cert.DNSNames
+	case CertificateEmailAddress:
+		return cert.EmailAddresses
+	case CertificateURI:
+		uris := make([]string, len(cert.URIs))
+		for n, uri := range cert.URIs {
+			uris[n] = uri.String()
+		}
+		return uris
+	}
+	return []string{}
+}
+
+// certificateMapping represents a rule which maps a certificate field to a username and group like pg_ident.conf.
+type certificateMapping struct {
+	field    CertificateField
+	re       *regexp.Regexp
+	username string
+	group    string
+}
+
+var backrefRegexp = regexp.MustCompile(`\\(\d+)`)
+
+// expandTemplate converts a pg_ident.conf style template into a regexp.Expand template.
+// A literal $ is escaped, and the backreferences such as \1 are converted into ${1}.
+func expandTemplate(template string) string {
+	template = strings.ReplaceAll(template, "$", "$$")
+	return backrefRegexp.ReplaceAllString(template, `$${$1}`)
+}
+
+// WithCertificateMapping adds a mapping rule to the certificate authenticator.
+// The pattern is matched against the values of the field in the leaf certificate, and the username and group
+// are expanded with the submatches. The submatches are referred by \1, \2 and so on as pg_ident.conf, and other characters
+// including $ are used literally.
+// The rules are evaluated in the order in which they are added, and the first matching rule determines the principal.
+// If any rule is added, a client certificate that matches no rule is rejected.
+// When the mapped principal is checked against a query, the queried group must equal the mapped group,
+// so a rule with an empty group only accepts queries without a group.
+func WithCertificateMapping(field CertificateField, pattern string, username string, group string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		switch field {
+		case CertificateSubject, CertificateCommonName, CertificateDNSName, CertificateEmailAddress, CertificateURI:
+		default:
+			return fmt.Errorf("unknown certificate field: %s", field)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		ca.mappings = append(ca.mappings, &certificateMapping{
+			field:    field,
+			re:       re,
+			username: expandTemplate(username),
+			group:    expandTemplate(group),
+		})
+		return nil
+	}
+}
+
+// Map returns the mapped username and group for the specified certificate.
+func (m *certificateMapping) Map(cert *x509.Certificate) (string, string, bool) {
+	for _, value := range m.field.Values(cert) {
+		submatches := m.re.FindStringSubmatchIndex(value)
+		if submatches == nil {
+			continue
+		}
+		username := string(m.re.ExpandString(nil, m.username, value, submatches))
+		group := string(m.re.ExpandString(nil, m.group, value, submatches))
+		return username, group, true
+	}
+	return "", "", false
+}
+
+// mapPrincipal returns the principal mapped by the first matching rule.
+// If the query is specified, only the rules which map to the queried username and group are considered.
+func (ca *certificateAuthenticator) mapPrincipal(cert *x509.Certificate, q Query) (Principal, bool) {
+	if len(ca.mappings) == 0 {
+		if q != nil && (q.Username() != cert.Subject.CommonName || 0 < len(q.Group())) {
+			return nil, false
+		}
+		return newCertificatePrincipal(cert), true
+	}
+	for _, m := range ca.mappings {
+		username, group, ok := m.Map(cert)
+		if !ok {
+			continue
+		}
+		if q != nil {
+			if username != q.Username() || group != q.Group() {
+				continue
+			}
+		}
+		return newCertificatePrincipal(cert,
+			WithPrincipalUsername(username),
+			WithPrincipalGroup(group),
+		), true
+	}
+	return nil, false
+}
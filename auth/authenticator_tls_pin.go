@@ -0,0 +1,95 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+	"slices"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+)
+
+func parseFingerprints(fps ...string) ([]tls.Fingerprint, error) {
+	parsed := make([]tls.Fingerprint, 0, len(fps))
+	for _, fp := range fps {
+		p, err := tls.ParseFingerprint(fp)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
+
+// WithCertificateFingerprint pins the leaf certificates by the SHA-256 fingerprints of the certificates in hex or base64.
+// A pinned client certificate is admitted independently of the issuer rules and CA trust.
+func WithCertificateFingerprint(fps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		parsed, err := parseFingerprints(fps...)
+		if err != nil {
+			return err
+		}
+		ca.certFingerprints = append(ca.certFingerprints, parsed...)
+		return nil
+	}
+}
+
+// WithPublicKeyFingerprint pins the leaf certificates by the SHA-256 fingerprints of the SubjectPublicKeyInfo in hex or base64.
+// A pinned client certificate is admitted independently of the issuer rules and CA trust, and survives re-issuance with the same key.
+func WithPublicKeyFingerprint(fps ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		parsed, err := parseFingerprints(fps...)
+		if err != nil {
+			return err
+		}
+		ca.publicKeyFingerprints = append(ca.publicKeyFingerprints, parsed...)
+		return nil
+	}
+}
+
+// WithCertificateFingerprintFile loads the certificate fingerprints from the file which has a fingerprint per line.
+func WithCertificateFingerprintFile(file string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		fps, err := tls.ParseFingerprintFile(file)
+		if err != nil {
+			return err
+		}
+		ca.certFingerprints = append(ca.certFingerprints, fps...)
+		return nil
+	}
+}
+
+// WithPublicKeyFingerprintFile loads the SubjectPublicKeyInfo fingerprints from the file which has a fingerprint per line.
+func WithPublicKeyFingerprintFile(file string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		fps, err := tls.ParseFingerprintFile(file)
+		if err != nil {
+			return err
+		}
+		ca.publicKeyFingerprints = append(ca.publicKeyFingerprints, fps...)
+		return nil
+	}
+}
+
+// matchFingerprint returns true if the specified certificate is pinned.
+func (ca *certificateAuthenticator) matchFingerprint(cert *x509.Certificate) bool {
+	if 0 < len(ca.certFingerprints) && slices.Contains(ca.certFingerprints, tls.CertificateFingerprint(cert)) {
+		return true
+	}
+	if 0 < len(ca.publicKeyFingerprints) && slices.Contains(ca.publicKeyFingerprints, tls.PublicKeyFingerprint(cert)) {
+		return true
+	}
+	return false
+}
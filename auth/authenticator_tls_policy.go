@@ -0,0 +1,190 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// extKeyUsageNames maps the extended key usages to their names in RFC 5280 and the vendor specifications.
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "any",
+	x509.ExtKeyUsageServerAuth:                     "serverAuth",
+	x509.ExtKeyUsageClientAuth:                     "clientAuth",
+	x509.ExtKeyUsageCodeSigning:                    "codeSigning",
+	x509.ExtKeyUsageEmailProtection:                "emailProtection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "ipsecEndSystem",
+	x509.ExtKeyUsageIPSECTunnel:                    "ipsecTunnel",
+	x509.ExtKeyUsageIPSECUser:                      "ipsecUser",
+	x509.ExtKeyUsageTimeStamping:                   "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSPSigning",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "msSGC",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "nsSGC",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "msCodeCom",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "msKernelCode",
+}
+
+// extKeyUsageName returns the name of the extended key usage, or its numeric value if it is unknown.
+func extKeyUsageName(usage x509.ExtKeyUsage) string {
+	if name, ok := extKeyUsageNames[usage]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExtKeyUsage(%d)", usage)
+}
+
+// extKeyUsagesString returns the extended key usages of the certificate by their names, and the unknown ones by their OIDs.
+func extKeyUsagesString(cert *x509.Certificate) string {
+	usages := []string{}
+	for _, usage := range cert.ExtKeyUsage {
+		usages = append(usages, extKeyUsageName(usage))
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		usages = append(usages, oid.String())
+	}
+	if len(usages) == 0 {
+		return "none"
+	}
+	return strings.Join(usages, ", ")
+}
+
+// certificatePolicy represents the policy which the leaf certificate must satisfy.
+type certificatePolicy struct {
+	extKeyUsages  []x509.ExtKeyUsage
+	publicKeyAlgs []x509.PublicKeyAlgorithm
+	minKeySizes   map[x509.PublicKeyAlgorithm]int
+	signatureAlgs []x509.SignatureAlgorithm
+	maxValidity   time.Duration
+	policyOIDs    []x509.OID
+}
+
+func newCertificatePolicy() *certificatePolicy {
+	return &certificatePolicy{
+		extKeyUsages:  []x509.ExtKeyUsage{},
+		publicKeyAlgs: []x509.PublicKeyAlgorithm{},
+		minKeySizes:   map[x509.PublicKeyAlgorithm]int{},
+		signatureAlgs: []x509.SignatureAlgorithm{},
+		maxValidity:   0,
+		policyOIDs:    []x509.OID{},
+	}
+}
+
+// WithRequiredExtKeyUsage requires the leaf certificate to have all of the extended key usages such as x509.ExtKeyUsageClientAuth.
+// A certificate with x509.ExtKeyUsageAny satisfies any extended key usage.
+func WithRequiredExtKeyUsage(usages ...x509.ExtKeyUsage) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.policy.extKeyUsages = append(ca.policy.extKeyUsages, usages...)
+		return nil
+	}
+}
+
+// WithPublicKeyAlgorithms restricts the public key algorithms of the leaf certificate.
+func WithPublicKeyAlgorithms(algs ...x509.PublicKeyAlgorithm) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.policy.publicKeyAlgs = append(ca.policy.publicKeyAlgs, algs...)
+		return nil
+	}
+}
+
+// WithMinimumKeySize sets the minimum key size in bits of the leaf certificate for the public key algorithm.
+// The key size is the modulus size for RSA and the curve size for ECDSA. Other algorithms are not supported.
+func WithMinimumKeySize(alg x509.PublicKeyAlgorithm, bits int) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		switch alg {
+		case x509.RSA, x509.ECDSA:
+		default:
+			return fmt.Errorf("minimum key size is not supported for %s", alg)
+		}
+		ca.policy.minKeySizes[alg] = bits
+		return nil
+	}
+}
+
+// WithSignatureAlgorithms restricts the signature algorithms of the leaf certificate.
+func WithSignatureAlgorithms(algs ...x509.SignatureAlgorithm) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.policy.signatureAlgs = append(ca.policy.signatureAlgs, algs...)
+		return nil
+	}
+}
+
+// WithMaxValidityPeriod sets the maximum validity period, from NotBefore to NotAfter, of the leaf certificate.
+func WithMaxValidityPeriod(d time.Duration) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		ca.policy.maxValidity = d
+		return nil
+	}
+}
+
+// WithRequiredPolicyOIDs requires the leaf certificate to have all of the certificate policy OIDs in dotted form such as "2.23.140.1.2.1".
+func WithRequiredPolicyOIDs(oids ...string) CertificateAuthenticatorOption {
+	return func(ca *certificateAuthenticator) error {
+		for _, s := range oids {
+			oid, err := x509.ParseOID(s)
+			if err != nil {
+				return err
+			}
+			ca.policy.policyOIDs = append(ca.policy.policyOIDs, oid)
+		}
+		return nil
+	}
+}
+
+// publicKeySize returns the key size in bits of the public key.
+func publicKeySize(cert *x509.Certificate) int {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return key.Curve.Params().BitSize
+	}
+	return 0
+}
+
+// Check returns an error wrapping ErrCertificatePolicy which describes the first violation of the specified certificate.
+func (policy *certificatePolicy) Check(cert *x509.Certificate) error {
+	for _, usage := range policy.extKeyUsages {
+		if !slices.Contains(cert.ExtKeyUsage, usage) && !slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageAny) {
+			return fmt.Errorf("%w: %s does not have the required extended key usage %s (has %s)", ErrCertificatePolicy, cert.Subject, extKeyUsageName(usage), extKeyUsagesString(cert))
+		}
+	}
+	if 0 < len(policy.publicKeyAlgs) && !slices.Contains(policy.publicKeyAlgs, cert.PublicKeyAlgorithm) {
+		return fmt.Errorf("%w: %s has a disallowed public key algorithm %s", ErrCertificatePolicy, cert.Subject, cert.PublicKeyAlgorithm)
+	}
+	if minSize, ok := policy.minKeySizes[cert.PublicKeyAlgorithm]; ok {
+		if size := publicKeySize(cert); size < minSize {
+			return fmt.Errorf("%w: %s has a %d-bit %s key, less than %d bits", ErrCertificatePolicy, cert.Subject, size, cert.PublicKeyAlgorithm, minSize)
+		}
+	}
+	if 0 < len(policy.signatureAlgs) && !slices.Contains(policy.signatureAlgs, cert.SignatureAlgorithm) {
+		return fmt.Errorf("%w: %s is signed with a disallowed signature algorithm %s", ErrCertificatePolicy, cert.Subject, cert.SignatureAlgorithm)
+	}
+	if 0 < policy.maxValidity {
+		if validity := cert.NotAfter.Sub(cert.NotBefore); policy.maxValidity < validity {
+			return fmt.Errorf("%w: %s has a validity period %s, longer than %s", ErrCertificatePolicy, cert.Subject, validity, policy.maxValidity)
+		}
+	}
+	for _, oid := range policy.policyOIDs {
+		if !slices.ContainsFunc(cert.Policies, oid.Equal) {
+			return fmt.Errorf("%w: %s does not have the required policy %s", ErrCertificatePolicy, cert.Subject, oid)
+		}
+	}
+	return nil
+}
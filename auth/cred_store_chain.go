@@ -0,0 +1,131 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// CredentialStorePrecedence represents how a credential store chain resolves a credential found in several stores.
+type CredentialStorePrecedence int
+
+const (
+	// CredentialStoreFirstFound returns the credential of the first store which has it.
+	CredentialStoreFirstFound CredentialStorePrecedence = iota
+	// CredentialStoreUnique consults every store and returns ErrDuplicateCredential if more than one store has the credential.
+	CredentialStoreUnique
+)
+
+// String returns the string representation of the precedence.
+func (p CredentialStorePrecedence) String() string {
+	switch p {
+	case CredentialStoreFirstFound:
+		return "first-found"
+	case CredentialStoreUnique:
+		return "unique"
+	}
+	return "unknown"
+}
+
+// credentialStoreScope represents a store of a credential store chain and the groups it serves.
+type credentialStoreScope struct {
+	store  CredentialStore
+	groups []string
+}
+
+// serves returns true if the store serves the group. A store with no groups serves every group.
+func (scope *credentialStoreScope) serves(group string) bool {
+	return len(scope.groups) == 0 || slices.Contains(scope.groups, group)
+}
+
+type credentialStoreChain struct {
+	stores     []*credentialStoreScope
+	precedence CredentialStorePrecedence
+}
+
+// CredentialStoreChainOption represents an option of the credential store chain.
+type CredentialStoreChainOption = func(*credentialStoreChain) error
+
+// WithCredentialStore appends a credential store to the chain. If groups are specified, the store is consulted only
+// for queries of the groups, or of no group, and only credentials of the groups are accepted from the store.
+func WithCredentialStore(store CredentialStore, groups ...string) CredentialStoreChainOption {
+	return func(chain *credentialStoreChain) error {
+		if store == nil {
+			return errors.New("credential store is nil")
+		}
+		chain.stores = append(chain.stores, &credentialStoreScope{
+			store:  store,
+			groups: groups,
+		})
+		return nil
+	}
+}
+
+// WithCredentialStorePrecedence sets how a credential found in several stores is resolved. The default is CredentialStoreFirstFound.
+func WithCredentialStorePrecedence(precedence CredentialStorePrecedence) CredentialStoreChainOption {
+	return func(chain *credentialStoreChain) error {
+		switch precedence {
+		case CredentialStoreFirstFound, CredentialStoreUnique:
+			chain.precedence = precedence
+			return nil
+		}
+		return fmt.Errorf("invalid credential store precedence: %d", precedence)
+	}
+}
+
+// NewCredentialStoreChain returns a new credential store which consults the stores in order.
+// An error from a store stops the lookup and is returned, so that a credential is never resolved
+// by a later store while an earlier store is unavailable.
+func NewCredentialStoreChain(opts ...CredentialStoreChainOption) (CredentialStore, error) {
+	chain := &credentialStoreChain{
+		stores:     []*credentialStoreScope{},
+		precedence: CredentialStoreFirstFound,
+	}
+	for _, opt := range opts {
+		if err := opt(chain); err != nil {
+			return nil, err
+		}
+	}
+	return chain, nil
+}
+
+// LookupCredential looks up a credential.
+func (chain *credentialStoreChain) LookupCredential(q Query) (Credential, bool, error) {
+	var found Credential
+	foundIndex := 0
+	for n, scope := range chain.stores {
+		if len(q.Group()) != 0 && !scope.serves(q.Group()) {
+			continue
+		}
+		cred, ok, err := scope.store.LookupCredential(q)
+		if err != nil {
+			return nil, false, fmt.Errorf("credential store #%d: %w", n, err)
+		}
+		if !ok || cred == nil || !scope.serves(cred.Group()) {
+			continue
+		}
+		if chain.precedence == CredentialStoreFirstFound {
+			return cred, true, nil
+		}
+		if found != nil {
+			return nil, false, fmt.Errorf("%w: %s in credential stores #%d and #%d", ErrDuplicateCredential, q.Username(), foundIndex, n)
+		}
+		found = cred
+		foundIndex = n
+	}
+	return found, found != nil, nil
+}
@@ -0,0 +1,527 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialFileFormat represents the format of a credential file.
+type CredentialFileFormat string
+
+const (
+	// CredentialFileYAML represents a YAML credential file.
+	CredentialFileYAML CredentialFileFormat = "yaml"
+	// CredentialFileJSON represents a JSON credential file.
+	CredentialFileJSON CredentialFileFormat = "json"
+	// CredentialFileTOML represents a TOML credential file.
+	CredentialFileTOML CredentialFileFormat = "toml"
+	// CredentialFileHtpasswd represents an Apache htpasswd file.
+	CredentialFileHtpasswd CredentialFileFormat = "htpasswd"
+)
+
+// CredentialFileError represents an error in a credential file. Line is zero if the line is unknown.
+type CredentialFileError struct {
+	File string
+	Line int
+	Err  error
+}
+
+// Error returns the error message prefixed with the file and the line.
+func (e *CredentialFileError) Error() string {
+	if e.Line <= 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CredentialFileError) Unwrap() error {
+	return e.Err
+}
+
+// credentialFileUser represents a user entry of a credential file.
+type credentialFileUser struct {
+	Group      string   `json:"group"      toml:"group"      yaml:"group"`
+	Username   string   `json:"username"   toml:"username"   yaml:"username"`
+	Password   string   `json:"password"   toml:"password"   yaml:"password"`
+	Mechanisms []string `json:"mechanisms" toml:"mechanisms" yaml:"mechanisms"`
+	Enabled    *bool    `json:"enabled"    toml:"enabled"    yaml:"enabled"`
+}
+
+// credentialFileContent represents the content of a credential file.
+type credentialFileContent struct {
+	Users []credentialFileUser `json:"users" toml:"users" yaml:"users"`
+}
+
+// fileCredential represents a credential read from a credential file.
+type fileCredential struct {
+	group        string
+	username     string
+	password     string
+	mechanisms   []string
+	hashRequired bool
+}
+
+// passwordHashRequired returns true if the password must be verified as a hash and never as a plaintext password.
+func (cred *fileCredential) passwordHashRequired() bool {
+	return cred.hashRequired
+}
+
+// Group returns the group.
+func (cred *fileCredential) Group() string {
+	return cred.group
+}
+
+// Username returns the username.
+func (cred *fileCredential) Username() string {
+	return cred.username
+}
+
+// Password returns the password, which may be a hash.
+func (cred *fileCredential) Password() any {
+	return cred.password
+}
+
+// allows returns true if the credential allows the mechanism. A credential with no mechanisms allows every mechanism,
+// and a credential with mechanisms does not allow a query without a mechanism.
+func (cred *fileCredential) allows(mech string) bool {
+	return len(cred.mechanisms) == 0 || slices.Contains(cred.mechanisms, strings.ToUpper(mech))
+}
+
+// FileCredentialStore is the interface for a credential store backed by a YAML, JSON, TOML or htpasswd credential file.
+type FileCredentialStore interface {
+	CredentialStore
+	// File returns the path of the credential file.
+	File() string
+	// Reload reads the credential file. The current credentials are kept if the file is invalid.
+	Reload() error
+}
+
+type fileCredentialStore struct {
+	file               string
+	format             CredentialFileFormat
+	reloadMutex        sync.Mutex
+	reloadInterval     time.Duration
+	reloadErrorHandler func(error)
+	reloadCheckedAt    time.Time
+	modTime            time.Time
+	size               int64
+	creds              atomic.Pointer[map[string]map[string]*fileCredential]
+}
+
+// FileCredentialStoreOption represents an option of the file credential store.
+type FileCredentialStoreOption = func(*fileCredentialStore) error
+
+// WithCredentialFileFormat sets the format of the credential file. By default, the format is detected by the file extension
+// (.yaml, .yml, .json, .toml or .htpasswd).
+func WithCredentialFileFormat(format CredentialFileFormat) FileCredentialStoreOption {
+	return func(store *fileCredentialStore) error {
+		switch format {
+		case CredentialFileYAML, CredentialFileJSON, CredentialFileTOML, CredentialFileHtpasswd:
+			store.format = format
+			return nil
+		}
+		return fmt.Errorf("unsupported credential file format: %s", format)
+	}
+}
+
+// WithCredentialFileReloadInterval enables reloading of the credential file. The file is checked for changes
+// at most once per interval on lookups, and is reloaded if its modification time or size has changed.
+func WithCredentialFileReloadInterval(interval time.Duration) FileCredentialStoreOption {
+	return func(store *fileCredentialStore) error {
+		store.reloadInterval = interval
+		return nil
+	}
+}
+
+// WithCredentialFileReloadErrorHandler sets the handler which is called when the credential file cannot be reloaded.
+func WithCredentialFileReloadErrorHandler(handler func(error)) FileCredentialStoreOption {
+	return func(store *fileCredentialStore) error {
+		store.reloadErrorHandler = handler
+		return nil
+	}
+}
+
+// NewFileCredentialStore returns a new credential store which reads the users from the credential file.
+// Each user has a group, a username, a password, which can be a bcrypt hash verified by NewPasswordHashAuthenticator,
+// the allowed mechanisms, and an enabled flag. Disabled users and users queried by a mechanism which they are not
+// allowed to use, or by no mechanism if their mechanisms are restricted, are not found. The file is validated,
+// and the errors are reported as CredentialFileError with the line.
+func NewFileCredentialStore(file string, opts ...FileCredentialStoreOption) (FileCredentialStore, error) {
+	store := &fileCredentialStore{
+		file:               file,
+		format:             "",
+		reloadMutex:        sync.Mutex{},
+		reloadInterval:     0,
+		reloadErrorHandler: nil,
+		reloadCheckedAt:    time.Time{},
+		modTime:            time.Time{},
+		size:               0,
+		creds:              atomic.Pointer[map[string]map[string]*fileCredential]{},
+	}
+	for _, opt := range opts {
+		if err := opt(store); err != nil {
+			return nil, err
+		}
+	}
+	if len(store.format) == 0 {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml":
+			store.format = CredentialFileYAML
+		case ".json":
+			store.format = CredentialFileJSON
+		case ".toml":
+			store.format = CredentialFileTOML
+		case ".htpasswd":
+			store.format = CredentialFileHtpasswd
+		default:
+			return nil, fmt.Errorf("unknown credential file format: %s", file)
+		}
+	}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	store.reloadCheckedAt = time.Now()
+	return store, nil
+}
+
+// File returns the path of the credential file.
+func (store *fileCredentialStore) File() string {
+	return store.file
+}
+
+// Reload reads the credential file. The current credentials are kept if the file is invalid.
+func (store *fileCredentialStore) Reload() error {
+	store.reloadMutex.Lock()
+	defer store.reloadMutex.Unlock()
+	return store.reload()
+}
+
+func (store *fileCredentialStore) reload() error {
+	fi, err := os.Stat(store.file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(store.file)
+	if err != nil {
+		return err
+	}
+	creds, err := parseCredentialFile(store.file, store.format, data)
+	if err != nil {
+		return err
+	}
+	store.creds.Store(&creds)
+	store.modTime = fi.ModTime()
+	store.size = fi.Size()
+	return nil
+}
+
+// pollFile reloads the credential file if it has changed. If another lookup is reloading the file,
+// the lookup does not wait and uses the current credentials.
+func (store *fileCredentialStore) pollFile() {
+	if store.reloadInterval <= 0 || !store.reloadMutex.TryLock() {
+		return
+	}
+	defer store.reloadMutex.Unlock()
+	if time.Since(store.reloadCheckedAt) < store.reloadInterval {
+		return
+	}
+	store.reloadCheckedAt = time.Now()
+	fi, err := os.Stat(store.file)
+	if err == nil && (!fi.ModTime().Equal(store.modTime) || fi.Size() != store.size) {
+		err = store.reload()
+	}
+	if err != nil && store.reloadErrorHandler != nil {
+		store.reloadErrorHandler(err)
+	}
+}
+
+// LookupCredential looks up a credential. If the query has no group, the credential is looked up by the username
+// in all groups, and ErrDuplicateCredential is returned if more than one group has the username.
+// The users of an htpasswd file have no group, and the group of the query is ignored.
+func (store *fileCredentialStore) LookupCredential(q Query) (Credential, bool, error) {
+	store.pollFile()
+	creds := *store.creds.Load()
+	var found *fileCredential
+	if store.format == CredentialFileHtpasswd {
+		found = creds[""][q.Username()]
+	} else if len(q.Group()) != 0 {
+		found = creds[q.Group()][q.Username()]
+	} else {
+		for _, users := range creds {
+			cred, ok := users[q.Username()]
+			if !ok {
+				continue
+			}
+			if found != nil {
+				return nil, false, fmt.Errorf("%w: %s in groups %s and %s", ErrDuplicateCredential, q.Username(), found.Group(), cred.Group())
+			}
+			found = cred
+		}
+	}
+	if found == nil || !found.allows(q.Mechanism()) {
+		return nil, false, nil
+	}
+	return found, true, nil
+}
+
+// parseCredentialFile parses and validates the credential file, and returns the enabled credentials indexed by the group and the username.
+func parseCredentialFile(file string, format CredentialFileFormat, data []byte) (map[string]map[string]*fileCredential, error) {
+	var content credentialFileContent
+	var lines []int
+	var err error
+	switch format {
+	case CredentialFileYAML:
+		lines, err = decodeCredentialYAML(data, &content)
+	case CredentialFileJSON:
+		lines, err = decodeCredentialJSON(data, &content)
+	case CredentialFileTOML:
+		lines, err = decodeCredentialTOML(data, &content)
+	case CredentialFileHtpasswd:
+		lines, err = decodeCredentialHtpasswd(data, &content)
+	default:
+		err = fmt.Errorf("unsupported credential file format: %s", format)
+	}
+	if err != nil {
+		var fileErr *CredentialFileError
+		if errors.As(err, &fileErr) {
+			fileErr.File = file
+			return nil, fileErr
+		}
+		return nil, &CredentialFileError{File: file, Line: 0, Err: err}
+	}
+	if len(lines) != len(content.Users) {
+		lines = make([]int, len(content.Users))
+	}
+
+	var errs []error
+	creds := map[string]map[string]*fileCredential{}
+	defined := map[string]int{}
+	for n, user := range content.Users {
+		userErr := func(err error) {
+			errs = append(errs, &CredentialFileError{File: file, Line: lines[n], Err: fmt.Errorf("users[%d]: %w", n, err)})
+		}
+		if len(user.Username) == 0 {
+			userErr(errors.New("username is required"))
+			continue
+		}
+		key := user.Group + "/" + user.Username
+		if line, ok := defined[key]; ok {
+			userErr(fmt.Errorf("duplicate user %s (first defined at line %d)", key, line))
+			continue
+		}
+		defined[key] = lines[n]
+		if len(user.Password) == 0 {
+			userErr(fmt.Errorf("password of %s is required", key))
+			continue
+		}
+		if err := checkPasswordHash(user.Password); err != nil {
+			userErr(fmt.Errorf("password of %s: %w", key, err))
+			continue
+		}
+		if format == CredentialFileHtpasswd && !isPasswordHash(user.Password) {
+			userErr(fmt.Errorf("password of %s: unsupported password hash format", key))
+			continue
+		}
+		mechs := make([]string, len(user.Mechanisms))
+		for i, mech := range user.Mechanisms {
+			if len(strings.TrimSpace(mech)) == 0 {
+				userErr(fmt.Errorf("mechanism of %s is empty", key))
+				break
+			}
+			mechs[i] = strings.ToUpper(strings.TrimSpace(mech))
+		}
+		if user.Enabled != nil && !*user.Enabled {
+			continue
+		}
+		users, ok := creds[user.Group]
+		if !ok {
+			users = map[string]*fileCredential{}
+			creds[user.Group] = users
+		}
+		users[user.Username] = &fileCredential{
+			group:        user.Group,
+			username:     user.Username,
+			password:     user.Password,
+			mechanisms:   mechs,
+			hashRequired: format == CredentialFileHtpasswd,
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+	return creds, nil
+}
+
+// lineOf returns the line number of the byte offset.
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// decodeCredentialYAML decodes the YAML credential file, and returns the lines of the user entries.
+func decodeCredentialYAML(data []byte, content *credentialFileContent) ([]int, error) {
+	newError := func(err error) error {
+		line := 0
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		return &CredentialFileError{File: "", Line: line, Err: err}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, newError(err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(content); err != nil && !errors.Is(err, io.EOF) {
+		return nil, newError(err)
+	}
+	var lines []int
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return lines, nil
+	}
+	doc := root.Content[0]
+	for n := 0; n+1 < len(doc.Content); n += 2 {
+		if doc.Content[n].Value != "users" {
+			continue
+		}
+		for _, user := range doc.Content[n+1].Content {
+			lines = append(lines, user.Line)
+		}
+	}
+	return lines, nil
+}
+
+// decodeCredentialJSON decodes the JSON credential file, and returns the lines of the user entries.
+func decodeCredentialJSON(data []byte, content *credentialFileContent) ([]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(content); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return nil, &CredentialFileError{File: "", Line: lineOf(data, syntaxErr.Offset), Err: err}
+		case errors.As(err, &typeErr):
+			return nil, &CredentialFileError{File: "", Line: lineOf(data, typeErr.Offset), Err: err}
+		}
+		return nil, err
+	}
+
+	// Walk the tokens again to find the offsets of the user entries.
+	var lines []int
+	dec = json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return lines, nil
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return lines, nil
+		}
+		if key, _ := tok.(string); key != "users" {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return lines, nil
+			}
+			continue
+		}
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+			return lines, nil
+		}
+		for dec.More() {
+			offset := dec.InputOffset()
+			for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+				offset++
+			}
+			lines = append(lines, lineOf(data, offset))
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return lines, nil
+			}
+		}
+		return lines, nil
+	}
+	return lines, nil
+}
+
+var tomlUsersRegexp = regexp.MustCompile(`(?m)^[ \t]*\[\[[ \t]*users[ \t]*\]\]`)
+
+// decodeCredentialTOML decodes the TOML credential file, and returns the lines of the user entries.
+func decodeCredentialTOML(data []byte, content *credentialFileContent) ([]int, error) {
+	md, err := toml.Decode(string(data), content)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &CredentialFileError{File: "", Line: parseErr.Position.Line, Err: err}
+		}
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) != 0 {
+		return nil, fmt.Errorf("unknown key: %s", undecoded[0])
+	}
+	var lines []int
+	for _, loc := range tomlUsersRegexp.FindAllIndex(data, -1) {
+		lines = append(lines, lineOf(data, int64(loc[0])))
+	}
+	return lines, nil
+}
+
+// decodeCredentialHtpasswd decodes the htpasswd file, and returns the lines of the user entries.
+// Each line has a username and a password hash separated by a colon, and blank lines and comments starting with # are skipped.
+func decodeCredentialHtpasswd(data []byte, content *credentialFileContent) ([]int, error) {
+	var lines []int
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, password, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, &CredentialFileError{File: "", Line: n + 1, Err: errors.New("missing colon between username and password")}
+		}
+		content.Users = append(content.Users, credentialFileUser{
+			Group:      "",
+			Username:   username,
+			Password:   password,
+			Mechanisms: nil,
+			Enabled:    nil,
+		})
+		lines = append(lines, n+1)
+	}
+	return lines, nil
+}
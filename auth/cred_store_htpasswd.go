@@ -0,0 +1,37 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+// NewHtpasswdCredentialStore returns a new credential store which reads the users from the Apache htpasswd file.
+// The password hashes can be bcrypt ($2y$), SHA-1 ({SHA}) or Apache MD5 ($apr1$), and are verified by NewPasswordHashAuthenticator.
+// An entry in any other format, such as a crypt(3) DES hash or a plaintext password, is rejected when the file is loaded.
+// The users have no group, and the group of the query is ignored. The options of NewFileCredentialStore, such as
+// WithCredentialFileReloadInterval, are available.
+func NewHtpasswdCredentialStore(file string, opts ...FileCredentialStoreOption) (FileCredentialStore, error) {
+	opts = append([]FileCredentialStoreOption{WithCredentialFileFormat(CredentialFileHtpasswd)}, opts...)
+	return NewFileCredentialStore(file, opts...)
+}
+
+// NewHtpasswdAuthenticator returns a new credential authenticator which verifies plaintext passwords
+// against the password hashes of the Apache htpasswd file. Manager::SetCredentialStore replaces the store of the authenticator.
+func NewHtpasswdAuthenticator(file string, opts ...FileCredentialStoreOption) (DefaultCredentialAuthenticator, error) {
+	store, err := NewHtpasswdCredentialStore(file, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ca := NewPasswordHashAuthenticator()
+	ca.SetCredentialStore(store)
+	return ca, nil
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cybergarage/go-sasl/sasl/auth"
+)
+
+// MemoryCredentialStore is the interface for an in-memory credential store which is safe for concurrent use.
+// The credentials are identified by the group and the username.
+type MemoryCredentialStore interface {
+	CredentialStore
+	// AddCredential adds a credential. It returns ErrCredentialExists if the credential already exists.
+	AddCredential(cred Credential) error
+	// UpdateCredential replaces a credential. It returns ErrCredentialNotFound if the credential does not exist.
+	UpdateCredential(cred Credential) error
+	// RemoveCredential removes a credential. It returns ErrCredentialNotFound if the credential does not exist.
+	RemoveCredential(group string, username string) error
+	// Credentials returns the credentials of the groups sorted by the group and the username, or all credentials if no groups are specified.
+	Credentials(groups ...string) []Credential
+	// Snapshot returns a copy of all credentials which can be restored later.
+	Snapshot() []Credential
+	// Restore replaces all credentials with the snapshot.
+	Restore(snapshot []Credential) error
+}
+
+type memoryCredentialStore struct {
+	sync.RWMutex
+	creds map[string]map[string]Credential
+}
+
+// NewMemoryCredentialStore returns a new in-memory credential store with the credentials.
+func NewMemoryCredentialStore(creds ...Credential) (MemoryCredentialStore, error) {
+	store := &memoryCredentialStore{
+		RWMutex: sync.RWMutex{},
+		creds:   map[string]map[string]Credential{},
+	}
+	if err := store.Restore(creds); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// cloneCredential returns a copy of the credential so that the caller cannot modify a stored password.
+func cloneCredential(cred Credential) Credential {
+	password := cred.Password()
+	if b, ok := password.([]byte); ok {
+		password = bytes.Clone(b)
+	}
+	return auth.NewCredential(
+		auth.WithCredentialGroup(cred.Group()),
+		auth.WithCredentialUsername(cred.Username()),
+		auth.WithCredentialPassword(password),
+	)
+}
+
+// newCredentialMap returns a map of the credentials indexed by the group and the username.
+func newCredentialMap(creds []Credential) (map[string]map[string]Credential, error) {
+	m := map[string]map[string]Credential{}
+	for _, cred := range creds {
+		if cred == nil || len(cred.Username()) == 0 {
+			return nil, errors.New("credential has no username")
+		}
+		users, ok := m[cred.Group()]
+		if !ok {
+			users = map[string]Credential{}
+			m[cred.Group()] = users
+		}
+		if _, ok := users[cred.Username()]; ok {
+			return nil, fmt.Errorf("%w: %s/%s", ErrCredentialExists, cred.Group(), cred.Username())
+		}
+		users[cred.Username()] = cloneCredential(cred)
+	}
+	return m, nil
+}
+
+// LookupCredential looks up a credential. If the query has no group, the credential is looked up by the username
+// in all groups, and ErrDuplicateCredential is returned if more than one group has the username.
+func (store *memoryCredentialStore) LookupCredential(q Query) (Credential, bool, error) {
+	store.RLock()
+	defer store.RUnlock()
+	if len(q.Group()) != 0 {
+		cred, ok := store.creds[q.Group()][q.Username()]
+		return cred, ok, nil
+	}
+	var found Credential
+	for _, users := range store.creds {
+		cred, ok := users[q.Username()]
+		if !ok {
+			continue
+		}
+		if found != nil {
+			return nil, false, fmt.Errorf("%w: %s in groups %s and %s", ErrDuplicateCredential, q.Username(), found.Group(), cred.Group())
+		}
+		found = cred
+	}
+	return found, found != nil, nil
+}
+
+// AddCredential adds a credential. It returns ErrCredentialExists if the credential already exists.
+func (store *memoryCredentialStore) AddCredential(cred Credential) error {
+	if cred == nil || len(cred.Username()) == 0 {
+		return errors.New("credential has no username")
+	}
+	store.Lock()
+	defer store.Unlock()
+	users, ok := store.creds[cred.Group()]
+	if !ok {
+		users = map[string]Credential{}
+		store.creds[cred.Group()] = users
+	}
+	if _, ok := users[cred.Username()]; ok {
+		return fmt.Errorf("%w: %s/%s", ErrCredentialExists, cred.Group(), cred.Username())
+	}
+	users[cred.Username()] = cloneCredential(cred)
+	return nil
+}
+
+// UpdateCredential replaces a credential. It returns ErrCredentialNotFound if the credential does not exist.
+func (store *memoryCredentialStore) UpdateCredential(cred Credential) error {
+	if cred == nil {
+		return errors.New("credential is nil")
+	}
+	store.Lock()
+	defer store.Unlock()
+	users := store.creds[cred.Group()]
+	if _, ok := users[cred.Username()]; !ok {
+		return fmt.Errorf("%w: %s/%s", ErrCredentialNotFound, cred.Group(), cred.Username())
+	}
+	users[cred.Username()] = cloneCredential(cred)
+	return nil
+}
+
+// RemoveCredential removes a credential. It returns ErrCredentialNotFound if the credential does not exist.
+func (store *memoryCredentialStore) RemoveCredential(group string, username string) error {
+	store.Lock()
+	defer store.Unlock()
+	users := store.creds[group]
+	if _, ok := users[username]; !ok {
+		return fmt.Errorf("%w: %s/%s", ErrCredentialNotFound, group, username)
+	}
+	delete(users, username)
+	if len(users) == 0 {
+		delete(store.creds, group)
+	}
+	return nil
+}
+
+// Credentials returns the credentials of the groups sorted by the group and the username, or all credentials if no groups are specified.
+func (store *memoryCredentialStore) Credentials(groups ...string) []Credential {
+	store.RLock()
+	defer store.RUnlock()
+	if len(groups) == 0 {
+		for group := range store.creds {
+			groups = append(groups, group)
+		}
+	}
+	creds := []Credential{}
+	for _, group := range groups {
+		for _, cred := range store.creds[group] {
+			creds = append(creds, cloneCredential(cred))
+		}
+	}
+	sort.Slice(creds, func(i, j int) bool {
+		if creds[i].Group() != creds[j].Group() {
+			return creds[i].Group() < creds[j].Group()
+		}
+		return creds[i].Username() < creds[j].Username()
+	})
+	return creds
+}
+
+// Snapshot returns a copy of all credentials which can be restored later.
+func (store *memoryCredentialStore) Snapshot() []Credential {
+	return store.Credentials()
+}
+
+// Restore replaces all credentials with the snapshot. The store is not changed if the snapshot is invalid.
+func (store *memoryCredentialStore) Restore(snapshot []Credential) error {
+	creds, err := newCredentialMap(snapshot)
+	if err != nil {
+		return err
+	}
+	store.Lock()
+	defer store.Unlock()
+	store.creds = creds
+	return nil
+}
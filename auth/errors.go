@@ -0,0 +1,36 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+
+	"github.com/cybergarage/go-sasl/sasl/auth"
+)
+
+// ErrNoCredentialStore is returned when a credential authenticator has no credential store.
+var ErrNoCredentialStore = auth.ErrNoCredentialStore
+
+// ErrCertificatePolicy is returned when a client certificate violates the certificate policy.
+var ErrCertificatePolicy = errors.New("certificate policy violation")
+
+// ErrDuplicateCredential is returned when a credential is found in more than one credential store of a chain.
+var ErrDuplicateCredential = errors.New("duplicate credential")
+
+// ErrCredentialExists is returned when a credential already exists in a credential store.
+var ErrCredentialExists = errors.New("credential already exists")
+
+// ErrCredentialNotFound is returned when a credential does not exist in a credential store.
+var ErrCredentialNotFound = errors.New("credential not found")
@@ -33,14 +33,24 @@ type Manager interface {
 	Mechanism(name string) (Mechanism, error)
 	// SetCredentialAuthenticator sets the credential authenticator.
 	SetCredentialAuthenticator(auth CredentialAuthenticator)
+	// SetCredentialAuthenticators sets an ordered chain of the credential authenticators.
+	SetCredentialAuthenticators(entries ...CredentialChainEntry)
 	// SetCredentialStore sets the credential store.
 	SetCredentialStore(store CredentialStore)
 	// CredentialStore returns the credential store.
 	CredentialStore() CredentialStore
 	// VerifyCredential verifies the client credential.
 	VerifyCredential(conn Conn, q Query) (bool, error)
+	// VerifyCredentialPrincipal verifies the client credential and returns the authenticated principal.
+	VerifyCredentialPrincipal(conn Conn, q Query) (Principal, bool, error)
+	// VerifyCredentialTrace verifies the client credential and returns the decision trace.
+	VerifyCredentialTrace(conn Conn, q Query) (*CredentialTrace, error)
 	// SetCertificateAuthenticator sets the certificate authenticator.
 	SetCertificateAuthenticator(auth CertificateAuthenticator)
 	// VerifyCertificate verifies the client certificate.
 	VerifyCertificate(conn tls.Conn) (bool, error)
+	// VerifyCertificatePrincipal verifies the client certificate and returns the authenticated principal.
+	VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error)
+	// VerifyCertificateQuery verifies the client certificate and checks that the principal matches the queried username and group.
+	VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error)
 }
@@ -21,15 +21,77 @@ import (
 
 type manager struct {
 	sasl.Server
+	// credAuthenticator holds the authenticator set by SetCredentialAuthenticator because sasl.Server does not expose it.
+	// It is always set together with the embedded server, which is not accessible from outside the manager, so the two do not drift.
+	credAuthenticator CredentialAuthenticator
 	certAuthenticator CertificateAuthenticator
 }
 
 // NewManager returns a new manager.
 func NewManager() Manager {
-	return &manager{
+	mgr := &manager{
+		credAuthenticator: nil,
 		certAuthenticator: nil,
 		Server:            sasl.NewServer(),
 	}
+	mgr.SetCredentialAuthenticator(NewCredentialAuthenticator())
+	return mgr
+}
+
+// SetCredentialAuthenticator sets the credential authenticator.
+// A credential authenticator chain is given the current credential store for the entries which have no store of their own.
+func (mgr *manager) SetCredentialAuthenticator(auth CredentialAuthenticator) {
+	mgr.credAuthenticator = auth
+	mgr.Server.SetCredentialAuthenticator(auth)
+	if chain, ok := auth.(CredentialAuthenticatorChain); ok {
+		if store := mgr.Server.CredentialStore(); store != nil {
+			chain.SetCredentialStore(store)
+		}
+	}
+}
+
+// SetCredentialAuthenticators sets an ordered chain of the credential authenticators.
+// The entries are evaluated in order according to their control flags, see NewCredentialAuthenticatorChain.
+func (mgr *manager) SetCredentialAuthenticators(entries ...CredentialChainEntry) {
+	mgr.SetCredentialAuthenticator(NewCredentialAuthenticatorChain(entries...))
+}
+
+// VerifyCredentialPrincipal verifies the client credential and returns the authenticated principal.
+// The principal is resolved by the credential authenticator which verified the credential. If the authenticator
+// does not resolve it, the principal is built from the credential looked up by the store which the authenticator
+// verifies with, see CredentialTrace. The group is taken from the credential rather than the client query.
+func (mgr *manager) VerifyCredentialPrincipal(conn Conn, q Query) (Principal, bool, error) {
+	trace, err := mgr.VerifyCredentialTrace(conn, q)
+	if !trace.Result {
+		return nil, false, err
+	}
+	return trace.Principal, true, err
+}
+
+// VerifyCredentialTrace verifies the client credential and returns the decision trace.
+// If the credential authenticator is not a chain, the trace has a single required decision.
+func (mgr *manager) VerifyCredentialTrace(conn Conn, q Query) (*CredentialTrace, error) {
+	if chain, ok := mgr.credAuthenticator.(CredentialAuthenticatorChain); ok {
+		return chain.VerifyCredentialTrace(conn, q)
+	}
+	var store CredentialStore
+	if _, ok := mgr.credAuthenticator.(CredentialStoreRegistrar); ok {
+		store = mgr.CredentialStore()
+	}
+	p, ok, err := verifyCredentialWith(mgr.credAuthenticator, credentialStoreOf(mgr.credAuthenticator, store), conn, q)
+	reason := "required entry \"default\" succeeded"
+	if !ok {
+		reason = "required entry \"default\" failed"
+	}
+	trace := &CredentialTrace{
+		Decisions: []CredentialDecision{
+			{Name: "default", Control: CredentialRequired, Result: ok, Err: err},
+		},
+		Result:    ok,
+		Reason:    reason,
+		Principal: p,
+	}
+	return trace, err
 }
 
 // SetCertificateAuthenticator sets the certificate authenticator.
@@ -44,3 +106,38 @@ func (mgr *manager) VerifyCertificate(conn tls.Conn) (bool, error) {
 	}
 	return mgr.certAuthenticator.VerifyCertificate(conn)
 }
+
+// VerifyCertificatePrincipal verifies the client certificate and returns the authenticated principal.
+// If the certificate authenticator does not resolve the principal, the principal is built from the leaf certificate.
+// If the certificate authenticator is not set and the client has no certificate, it returns a nil principal and true.
+func (mgr *manager) VerifyCertificatePrincipal(conn tls.Conn) (Principal, bool, error) {
+	if pa, ok := mgr.certAuthenticator.(CertificatePrincipalAuthenticator); ok {
+		return pa.VerifyCertificatePrincipal(conn)
+	}
+	ok, err := mgr.VerifyCertificate(conn)
+	if !ok {
+		return nil, false, err
+	}
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, true, err
+	}
+	return newCertificatePrincipal(state.PeerCertificates[0]), true, err
+}
+
+// VerifyCertificateQuery verifies the client certificate and checks that the principal matches the queried username and group.
+// If the certificate authenticator does not resolve the principal, the username is compared with the common name of the leaf
+// certificate, and the group of the query must be empty because the leaf certificate has no group.
+func (mgr *manager) VerifyCertificateQuery(conn tls.Conn, q Query) (Principal, bool, error) {
+	if pa, ok := mgr.certAuthenticator.(CertificatePrincipalAuthenticator); ok {
+		return pa.VerifyCertificateQuery(conn, q)
+	}
+	p, ok, err := mgr.VerifyCertificatePrincipal(conn)
+	if !ok || p == nil {
+		return nil, false, err
+	}
+	if p.Username() != q.Username() || p.Group() != q.Group() {
+		return nil, false, err
+	}
+	return p, true, err
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+)
+
+// AuthMethod represents an authentication method.
+type AuthMethod string
+
+const (
+	// AuthMethodNone represents that the principal is not authenticated.
+	AuthMethodNone AuthMethod = ""
+	// AuthMethodCredential represents the credential authentication.
+	AuthMethodCredential AuthMethod = "credential"
+	// AuthMethodCertificate represents the TLS certificate authentication.
+	AuthMethodCertificate AuthMethod = "certificate"
+)
+
+// Principal represents an authenticated identity.
+type Principal interface {
+	// Username returns the username.
+	Username() string
+	// Group returns the group.
+	Group() string
+	// AuthMethod returns the authentication method.
+	AuthMethod() AuthMethod
+	// Mechanism returns the mechanism name used for the authentication.
+	Mechanism() string
+	// Attribute returns the attribute value by name.
+	Attribute(name string) (any, bool)
+	// Attributes returns all attributes.
+	Attributes() map[string]any
+	// Certificate returns the source certificate if the principal was authenticated by a certificate.
+	Certificate() *x509.Certificate
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+	"maps"
+)
+
+type principal struct {
+	username   string
+	group      string
+	method     AuthMethod
+	mechanism  string
+	attributes map[string]any
+	cert       *x509.Certificate
+}
+
+// PrincipalOptionFn represents an option function for a principal.
+type PrincipalOptionFn func(*principal)
+
+// NewPrincipal returns a new principal with options.
+func NewPrincipal(opts ...PrincipalOptionFn) Principal {
+	p := &principal{
+		username:   "",
+		group:      "",
+		method:     AuthMethodNone,
+		mechanism:  "",
+		attributes: map[string]any{},
+		cert:       nil,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithPrincipalUsername returns an option to set the username.
+func WithPrincipalUsername(username string) PrincipalOptionFn {
+	return func(p *principal) {
+		p.username = username
+	}
+}
+
+// WithPrincipalGroup returns an option to set the group.
+func WithPrincipalGroup(group string) PrincipalOptionFn {
+	return func(p *principal) {
+		p.group = group
+	}
+}
+
+// WithPrincipalAuthMethod returns an option to set the authentication method.
+func WithPrincipalAuthMethod(method AuthMethod) PrincipalOptionFn {
+	return func(p *principal) {
+		p.method = method
+	}
+}
+
+// WithPrincipalMechanism returns an option to set the mechanism name.
+func WithPrincipalMechanism(mech string) PrincipalOptionFn {
+	return func(p *principal) {
+		p.mechanism = mech
+	}
+}
+
+// WithPrincipalAttribute returns an option to set an attribute.
+func WithPrincipalAttribute(name string, value any) PrincipalOptionFn {
+	return func(p *principal) {
+		p.attributes[name] = value
+	}
+}
+
+// WithPrincipalCertificate returns an option to set the source certificate.
+func WithPrincipalCertificate(cert *x509.Certificate) PrincipalOptionFn {
+	return func(p *principal) {
+		p.cert = cert
+	}
+}
+
+// newCertificatePrincipal returns a new principal for the specified certificate.
+// The username defaults to the subject common name of the certificate.
+func newCertificatePrincipal(cert *x509.Certificate, opts ...PrincipalOptionFn) Principal {
+	opts = append([]PrincipalOptionFn{
+		WithPrincipalUsername(cert.Subject.CommonName),
+		WithPrincipalAuthMethod(AuthMethodCertificate),
+		WithPrincipalCertificate(cert),
+	}, opts...)
+	return NewPrincipal(opts...)
+}
+
+// newCredentialPrincipal returns a new principal for the specified query and credential.
+// The group is taken from the credential because the group in the query is claimed by the client.
+// If the credential is nil, the group is empty.
+func newCredentialPrincipal(q Query, cred Credential, opts ...PrincipalOptionFn) Principal {
+	username := q.Username()
+	group := ""
+	if cred != nil {
+		username = cred.Username()
+		group = cred.Group()
+	}
+	opts = append([]PrincipalOptionFn{
+		WithPrincipalUsername(username),
+		WithPrincipalGroup(group),
+		WithPrincipalAuthMethod(AuthMethodCredential),
+		WithPrincipalMechanism(q.Mechanism()),
+	}, opts...)
+	return NewPrincipal(opts...)
+}
+
+// Username returns the username.
+func (p *principal) Username() string {
+	return p.username
+}
+
+// Group returns the group.
+func (p *principal) Group() string {
+	return p.group
+}
+
+// AuthMethod returns the authentication method.
+func (p *principal) AuthMethod() AuthMethod {
+	return p.method
+}
+
+// Mechanism returns the mechanism name used for the authentication.
+func (p *principal) Mechanism() string {
+	return p.mechanism
+}
+
+// Attribute returns the attribute value by name.
+func (p *principal) Attribute(name string) (any, bool) {
+	v, ok := p.attributes[name]
+	return v, ok
+}
+
+// Attributes returns all attributes.
+func (p *principal) Attributes() map[string]any {
+	return maps.Clone(p.attributes)
+}
+
+// Certificate returns the source certificate if the principal was authenticated by a certificate.
+func (p *principal) Certificate() *x509.Certificate {
+	return p.cert
+}
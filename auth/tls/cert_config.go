@@ -16,6 +16,8 @@ package tls
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"time"
 )
 
 // CertConfig represents a TLS configuration interface.
@@ -23,20 +25,105 @@ type CertConfig interface {
 	// SetClientAuthType sets a client authentication type.
 	SetClientAuthType(authType tls.ClientAuthType)
 	// SetServerKey sets a SSL server key.
+	// An encrypted PKCS#8 key ("ENCRYPTED PRIVATE KEY") is decrypted by the passphrase provider set by SetServerKeyPassphraseProvider.
 	SetServerKey(key []byte)
+	// SetServerKeyPassphraseProvider sets the provider of the passphrase to decrypt the encrypted PKCS#8 server keys.
+	// The provider is called whenever the keys are loaded, so the passphrase is not kept in the configuration.
+	SetServerKeyPassphraseProvider(provider PassphraseProvider)
+	// SetServerPKCS12 sets the SSL server key, certificate and chain from a PKCS#12 bundle which is decrypted by the passphrase of the provider.
+	SetServerPKCS12(data []byte, provider PassphraseProvider) error
+	// SetServerPKCS12File loads a PKCS#12 bundle file such as .p12 and .pfx, and sets it as SetServerPKCS12.
+	SetServerPKCS12File(file string, provider PassphraseProvider) error
 	// SetServerCert sets a SSL server certificate.
 	SetServerCert(cert []byte)
-	// SetRootCerts sets a SSL root certificates.
+	// AddServerKeyPair adds a SSL server certificate and key which is selected by the SNI server names such as "db.example.com" and "*.example.com".
+	// If no server names are specified, the DNS names of the certificate are used.
+	// The key pair set by SetServerCert and SetServerKey, or the first added key pair if it is not set, is the default for unmatched names.
+	AddServerKeyPair(cert []byte, key []byte, serverNames ...string)
+	// AddServerKeyPairFiles loads a SSL server certificate file and key file, and adds them as AddServerKeyPair.
+	AddServerKeyPairFiles(certFile string, keyFile string, serverNames ...string) error
+	// SetRootCerts sets a SSL root certificates, which are used for RootCAs and also for ClientCAs unless SetClientCACerts is set.
 	SetRootCerts(certs ...[]byte)
 	// SetServerKeyFile loads a SSL server key file and sets it.
 	SetServerKeyFile(file string) error
 	// SetServerCertFile loads a SSL server certificate file and sets it.
 	SetServerCertFile(file string) error
 	// SetRootCertFile loads SSL root certificate files and sets them.
+	// An error is returned if any file has no valid PEM or DER encoded certificates.
 	SetRootCertFiles(files ...string) error
+	// RootCertificates parses the root certificates and returns them. The error reports which file or index fails and why.
+	RootCertificates() ([]*x509.Certificate, error)
+	// SetClientCACerts sets SSL CA certificates to verify client certificates, which are used for ClientCAs instead of the root certificates.
+	SetClientCACerts(certs ...[]byte)
+	// SetClientCACertFiles loads SSL CA certificate files to verify client certificates and sets them.
+	SetClientCACertFiles(files ...string) error
+	// ClientCACertificates parses the CA certificates to verify client certificates and returns them.
+	ClientCACertificates() ([]*x509.Certificate, error)
+	// SetSystemRootCAs includes the system root certificates in RootCAs in addition to the root certificates if true.
+	SetSystemRootCAs(enabled bool)
+	// SetSystemClientCAs includes the system root certificates in ClientCAs in addition to the client CA certificates if true.
+	SetSystemClientCAs(enabled bool)
+	// CertificateExpiries returns the expiries of the server certificates with their chains, the root certificates,
+	// the client CA certificates and the trust bundles. It is safe to call concurrently with reloading.
+	CertificateExpiries() ([]CertificateExpiry, error)
+	// Validate checks that the server keys match the certificates, the root certificates and trust bundles are valid,
+	// and the chain of each server certificate builds to the root certificates, or to the system roots if no root certificates are set.
+	Validate() error
+	// SetTrustBundle sets SPIFFE trust bundle certificates for the trust domain.
+	// If any trust bundle is set, a client certificate with a SPIFFE ID must be issued by the bundle of its trust domain,
+	// and the client authentication type must be tls.RequireAndVerifyClientCert or tls.VerifyClientCertIfGiven.
+	SetTrustBundle(trustDomain string, certs ...[]byte)
+	// SetTrustBundleFiles loads SPIFFE trust bundle certificate files for the trust domain and sets them.
+	SetTrustBundleFiles(trustDomain string, files ...string) error
+	// SetCRLs sets PEM or DER encoded certificate revocation lists.
+	// If any list is set, revoked client certificates are rejected during the handshake.
+	SetCRLs(crls ...[]byte)
+	// SetCRLFiles loads certificate revocation list files and sets them. The files are reloaded by SetCRLReloadInterval.
+	SetCRLFiles(files ...string) error
+	// SetCRLReloadInterval sets the interval to reload the certificate revocation list files.
+	// The files are reloaded on a revocation check after the interval has elapsed. The files are not reloaded if the interval is zero.
+	SetCRLReloadInterval(interval time.Duration)
+	// SetCRLReloadErrorHandler sets the handler which is called when reloading the certificate revocation list files fails.
+	// The current lists are kept on failure.
+	SetCRLReloadErrorHandler(handler func(error))
+	// SetRevocationCheckers sets additional revocation checkers such as NewOCSPChecker(), which run after the certificate revocation lists.
+	SetRevocationCheckers(checkers ...RevocationChecker)
+	// RevocationChecker returns the revocation checker used during the handshake, which can be shared with the certificate authenticator.
+	RevocationChecker() RevocationChecker
+	// SetReloadInterval enables the reloading mode which polls the files set by SetServerCertFile, SetServerKeyFile, AddServerKeyPairFiles, SetRootCertFiles and SetClientCACertFiles
+	// at the interval, and swaps the key pair and root certificates atomically when any of them has changed.
+	// The files are polled on a handshake after the interval has elapsed, and the handshake blocks while the files are stat'ed and,
+	// if changed, read and parsed, so the interval should not be too short for slow file systems. A failed reload is retried by the
+	// following polls until it succeeds. The files are not reloaded if the interval is zero.
+	SetReloadInterval(interval time.Duration)
+	// SetReloadErrorHandler sets the handler which is called when reloading the files fails. The current configuration is kept on failure.
+	SetReloadErrorHandler(handler func(error))
+	// Reload reloads the files set by SetServerCertFile, SetServerKeyFile, AddServerKeyPairFiles, SetRootCertFiles and SetClientCACertFiles, and swaps the configuration.
+	Reload() error
+	// GetCertificate returns the current server certificate for the SNI server name, which can be set to tls.Config.GetCertificate.
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// GetConfigForClient returns the current TLS configuration, which can be set to tls.Config.GetConfigForClient.
+	GetConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error)
+	// SetTLSPolicy sets the protocol versions, cipher suites and curve preferences of the policy such as ModernTLSPolicy().
+	// The settings can be overridden by the following setters.
+	SetTLSPolicy(policy TLSPolicy)
+	// SetMinVersion sets the minimum TLS version such as tls.VersionTLS12, which is the default.
+	SetMinVersion(version uint16)
+	// SetMaxVersion sets the maximum TLS version. Zero means the maximum version supported by crypto/tls.
+	SetMaxVersion(version uint16)
+	// SetCipherSuites sets the TLS 1.0–1.2 cipher suites. Unknown and insecure cipher suites are rejected by TLSConfig.
+	SetCipherSuites(suites ...uint16)
+	// SetCurvePreferences sets the key exchange mechanisms in preference order.
+	SetCurvePreferences(curves ...tls.CurveID)
+	// SetALPNProtocols sets the ALPN protocols in preference order such as "h2" and "postgresql".
+	SetALPNProtocols(protos ...string)
+	// SetSessionTicketsDisabled disables the session ticket resumption if true.
+	SetSessionTicketsDisabled(disabled bool)
 	// SetTLSConfig sets a TLS configuration directly.
 	// If the provided configuration is nil, TLS will be disabled.
 	SetTLSConfig(tlsConfig *tls.Config)
 	// TLSConfig returns a TLS configuration from the configuration.
+	// In the reloading mode, the returned configuration serves the current configuration by GetCertificate and GetConfigForClient,
+	// so listeners created with it pick up the reloaded files without restart.
 	TLSConfig() (*tls.Config, error)
 }
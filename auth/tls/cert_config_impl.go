@@ -17,28 +17,86 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // certConfig represents a TLS configuration.
 type certConfig struct {
-	ClientAuthType tls.ClientAuthType
-	ServerCert     []byte
-	ServerKey      []byte
-	RootCerts      [][]byte
-	enabled        bool
-	tlsConfig      *tls.Config
+	ClientAuthType         tls.ClientAuthType
+	ServerCert             []byte
+	ServerKey              []byte
+	RootCerts              [][]byte
+	ServerCertFile         string
+	ServerKeyFile          string
+	RootCertFiles          []string
+	ClientCACerts          [][]byte
+	ClientCACertFiles      []string
+	SystemRootCAs          bool
+	SystemClientCAs        bool
+	serverKeyPairs         []*serverKeyPair
+	passphraseProvider     PassphraseProvider
+	MinVersion             uint16
+	MaxVersion             uint16
+	CipherSuites           []uint16
+	CurvePreferences       []tls.CurveID
+	ALPNProtocols          []string
+	SessionTicketsDisabled bool
+	TrustBundles           map[string][][]byte
+	CRLs                   [][]byte
+	CRLFiles               []string
+	crlChecker             *crlChecker
+	revCheckers            []RevocationChecker
+	reloadMutex            sync.Mutex
+	reloadInterval         time.Duration
+	reloadErrorHandler     func(error)
+	reloadCheckedAt        time.Time
+	watcher                *fileWatcher
+	currentConfig          atomic.Pointer[tls.Config]
+	enabled                bool
+	tlsConfig              *tls.Config
 }
 
 // NewCertConfig returns a new TLS configuration.
 func NewCertConfig() CertConfig {
 	return &certConfig{
-		ClientAuthType: tls.RequireAndVerifyClientCert,
-		ServerCert:     []byte{},
-		ServerKey:      []byte{},
-		RootCerts:      [][]byte{},
-		tlsConfig:      nil,
-		enabled:        false,
+		ClientAuthType:         tls.RequireAndVerifyClientCert,
+		ServerCert:             []byte{},
+		ServerKey:              []byte{},
+		RootCerts:              [][]byte{},
+		ServerCertFile:         "",
+		ServerKeyFile:          "",
+		RootCertFiles:          []string{},
+		ClientCACerts:          [][]byte{},
+		ClientCACertFiles:      []string{},
+		SystemRootCAs:          false,
+		SystemClientCAs:        false,
+		serverKeyPairs:         []*serverKeyPair{},
+		passphraseProvider:     nil,
+		MinVersion:             tls.VersionTLS12,
+		MaxVersion:             0,
+		CipherSuites:           []uint16{},
+		CurvePreferences:       []tls.CurveID{},
+		ALPNProtocols:          []string{},
+		SessionTicketsDisabled: false,
+		TrustBundles:           map[string][][]byte{},
+		CRLs:                   [][]byte{},
+		CRLFiles:               []string{},
+		crlChecker:             newCRLChecker(),
+		revCheckers:            []RevocationChecker{},
+		reloadMutex:            sync.Mutex{},
+		reloadInterval:         0,
+		reloadErrorHandler:     nil,
+		reloadCheckedAt:        time.Time{},
+		watcher:                nil,
+		currentConfig:          atomic.Pointer[tls.Config]{},
+		tlsConfig:              nil,
+		enabled:                false,
 	}
 }
 
@@ -66,6 +124,7 @@ func (config *certConfig) SetServerKeyFile(file string) error {
 		return err
 	}
 	config.SetServerKey(key)
+	config.ServerKeyFile = file
 	return nil
 }
 
@@ -76,10 +135,12 @@ func (config *certConfig) SetServerCertFile(file string) error {
 		return err
 	}
 	config.SetServerCert(cert)
+	config.ServerCertFile = file
 	return nil
 }
 
 // SetRootCertFile loads SSL root certificate files and sets them.
+// An error is returned if any file has no valid PEM or DER encoded certificates.
 func (config *certConfig) SetRootCertFiles(files ...string) error {
 	certs := make([][]byte, len(files))
 	for n, file := range files {
@@ -87,15 +148,58 @@ func (config *certConfig) SetRootCertFiles(files ...string) error {
 		if err != nil {
 			return err
 		}
+		if _, err := ParseCertificates(cert); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
 		certs[n] = cert
 	}
 	config.SetRootCerts(certs...)
+	config.RootCertFiles = files
+	return nil
+}
+
+// SetClientCACertFiles loads SSL CA certificate files to verify client certificates and sets them.
+func (config *certConfig) SetClientCACertFiles(files ...string) error {
+	certs := make([][]byte, len(files))
+	for n, file := range files {
+		cert, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := ParseCertificates(cert); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		certs[n] = cert
+	}
+	config.SetClientCACerts(certs...)
+	config.ClientCACertFiles = files
 	return nil
 }
 
+// SetClientCACerts sets SSL CA certificates to verify client certificates.
+func (config *certConfig) SetClientCACerts(certs ...[]byte) {
+	config.ClientCACerts = certs
+	config.ClientCACertFiles = []string{}
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+}
+
+// SetSystemRootCAs includes the system root certificates in RootCAs if true.
+func (config *certConfig) SetSystemRootCAs(enabled bool) {
+	config.SystemRootCAs = enabled
+	config.tlsConfig = nil
+}
+
+// SetSystemClientCAs includes the system root certificates in ClientCAs if true.
+func (config *certConfig) SetSystemClientCAs(enabled bool) {
+	config.SystemClientCAs = enabled
+	config.tlsConfig = nil
+}
+
 // SetServerKey sets a SSL server key.
 func (config *certConfig) SetServerKey(key []byte) {
 	config.ServerKey = key
+	config.ServerKeyFile = ""
 	config.tlsConfig = nil
 	config.SetTLSEnabled(true)
 }
@@ -103,21 +207,196 @@ func (config *certConfig) SetServerKey(key []byte) {
 // SetServerCert sets a SSL server certificate.
 func (config *certConfig) SetServerCert(cert []byte) {
 	config.ServerCert = cert
+	config.ServerCertFile = ""
 	config.tlsConfig = nil
 	config.SetTLSEnabled(true)
 }
 
+// SetServerKeyPassphraseProvider sets the provider of the passphrase to decrypt the encrypted PKCS#8 server keys.
+func (config *certConfig) SetServerKeyPassphraseProvider(provider PassphraseProvider) {
+	config.passphraseProvider = provider
+	config.tlsConfig = nil
+}
+
+// SetServerPKCS12File loads a PKCS#12 bundle file and sets it.
+func (config *certConfig) SetServerPKCS12File(file string, provider PassphraseProvider) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := config.SetServerPKCS12(data, provider); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// SetServerPKCS12 sets the SSL server key, certificate and chain from a PKCS#12 bundle.
+func (config *certConfig) SetServerPKCS12(data []byte, provider PassphraseProvider) error {
+	cert, key, err := decodePKCS12(data, provider)
+	if err != nil {
+		return err
+	}
+	config.SetServerCert(cert)
+	config.SetServerKey(key)
+	return nil
+}
+
+// AddServerKeyPair adds a SSL server certificate and key which is selected by the SNI server names.
+func (config *certConfig) AddServerKeyPair(cert []byte, key []byte, serverNames ...string) {
+	config.serverKeyPairs = append(config.serverKeyPairs, &serverKeyPair{
+		cert:        cert,
+		key:         key,
+		certFile:    "",
+		keyFile:     "",
+		serverNames: serverNames,
+	})
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+}
+
+// AddServerKeyPairFiles loads a SSL server certificate file and key file, and adds them.
+func (config *certConfig) AddServerKeyPairFiles(certFile string, keyFile string, serverNames ...string) error {
+	cert, err := os.ReadFile(certFile)
+	if err != nil {
+		return err
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	config.AddServerKeyPair(cert, key, serverNames...)
+	pair := config.serverKeyPairs[len(config.serverKeyPairs)-1]
+	pair.certFile = certFile
+	pair.keyFile = keyFile
+	return nil
+}
+
 // SetRootCerts sets a SSL root certificates.
 func (config *certConfig) SetRootCerts(certs ...[]byte) {
 	config.RootCerts = certs
+	config.RootCertFiles = []string{}
 	config.tlsConfig = nil
 	config.SetTLSEnabled(true)
 }
 
+// SetTrustBundleFiles loads SPIFFE trust bundle certificate files for the trust domain and sets them.
+func (config *certConfig) SetTrustBundleFiles(trustDomain string, files ...string) error {
+	certs := make([][]byte, len(files))
+	for n, file := range files {
+		cert, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		certs[n] = cert
+	}
+	config.SetTrustBundle(trustDomain, certs...)
+	return nil
+}
+
+// SetTrustBundle sets SPIFFE trust bundle certificates for the trust domain.
+func (config *certConfig) SetTrustBundle(trustDomain string, certs ...[]byte) {
+	config.TrustBundles[trustDomain] = certs
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+}
+
+// SetCRLs sets PEM or DER encoded certificate revocation lists.
+func (config *certConfig) SetCRLs(crls ...[]byte) {
+	config.CRLs = crls
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+}
+
+// SetCRLFiles loads certificate revocation list files and sets them.
+func (config *certConfig) SetCRLFiles(files ...string) error {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := parseCRLs(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	config.CRLFiles = files
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+	return nil
+}
+
+// SetCRLReloadInterval sets the interval to reload the certificate revocation list files.
+func (config *certConfig) SetCRLReloadInterval(interval time.Duration) {
+	config.crlChecker.SetReloadInterval(interval)
+}
+
+// SetCRLReloadErrorHandler sets the handler which is called when reloading the certificate revocation list files fails.
+func (config *certConfig) SetCRLReloadErrorHandler(handler func(error)) {
+	config.crlChecker.SetErrorHandler(handler)
+}
+
+// SetRevocationCheckers sets additional revocation checkers which run after the certificate revocation lists.
+func (config *certConfig) SetRevocationCheckers(checkers ...RevocationChecker) {
+	config.revCheckers = checkers
+	config.tlsConfig = nil
+	config.SetTLSEnabled(true)
+}
+
+// RevocationChecker returns the revocation checker used during the handshake.
+// The certificate revocation lists are loaded by TLSConfig.
+func (config *certConfig) RevocationChecker() RevocationChecker {
+	return append(revocationCheckers{config.crlChecker}, config.revCheckers...)
+}
+
+// SetTLSPolicy sets the protocol versions, cipher suites and curve preferences of the policy.
+func (config *certConfig) SetTLSPolicy(policy TLSPolicy) {
+	config.MinVersion = policy.MinVersion
+	config.MaxVersion = policy.MaxVersion
+	config.CipherSuites = slices.Clone(policy.CipherSuites)
+	config.CurvePreferences = slices.Clone(policy.CurvePreferences)
+	config.tlsConfig = nil
+}
+
+// SetMinVersion sets the minimum TLS version.
+func (config *certConfig) SetMinVersion(version uint16) {
+	config.MinVersion = version
+	config.tlsConfig = nil
+}
+
+// SetMaxVersion sets the maximum TLS version.
+func (config *certConfig) SetMaxVersion(version uint16) {
+	config.MaxVersion = version
+	config.tlsConfig = nil
+}
+
+// SetCipherSuites sets the TLS 1.0–1.2 cipher suites.
+func (config *certConfig) SetCipherSuites(suites ...uint16) {
+	config.CipherSuites = suites
+	config.tlsConfig = nil
+}
+
+// SetCurvePreferences sets the key exchange mechanisms in preference order.
+func (config *certConfig) SetCurvePreferences(curves ...tls.CurveID) {
+	config.CurvePreferences = curves
+	config.tlsConfig = nil
+}
+
+// SetALPNProtocols sets the ALPN protocols in preference order.
+func (config *certConfig) SetALPNProtocols(protos ...string) {
+	config.ALPNProtocols = protos
+	config.tlsConfig = nil
+}
+
+// SetSessionTicketsDisabled disables the session ticket resumption if true.
+func (config *certConfig) SetSessionTicketsDisabled(disabled bool) {
+	config.SessionTicketsDisabled = disabled
+	config.tlsConfig = nil
+}
+
 // SetTLSConfig sets a TLS configuration directly.
 // If the provided configuration is nil, TLS will be disabled.
 func (config *certConfig) SetTLSConfig(tlsConfig *tls.Config) {
 	config.tlsConfig = tlsConfig
+	config.currentConfig.Store(nil)
 	if tlsConfig != nil {
 		config.SetTLSEnabled(true)
 	} else {
@@ -133,20 +412,290 @@ func (config *certConfig) TLSConfig() (*tls.Config, error) {
 	if config.tlsConfig != nil {
 		return config.tlsConfig, nil
 	}
-	serverCert, err := tls.X509KeyPair(config.ServerCert, config.ServerKey)
+	tlsConfig, err := config.newTLSConfig()
 	if err != nil {
 		return nil, err
 	}
-	certPool := x509.NewCertPool()
-	for _, rootCert := range config.RootCerts {
-		certPool.AppendCertsFromPEM(rootCert)
+	if config.reloadInterval <= 0 {
+		config.currentConfig.Store(nil)
+		config.tlsConfig = tlsConfig
+		return config.tlsConfig, nil
 	}
+	config.reloadMutex.Lock()
+	config.currentConfig.Store(tlsConfig)
+	config.watcher = newFileWatcher(config.reloadFiles()...)
+	config.reloadCheckedAt = time.Now()
+	config.reloadMutex.Unlock()
 	config.tlsConfig = &tls.Config{ // nolint: exhaustruct
-		MinVersion:   tls.VersionTLS12,
-		Certificates: []tls.Certificate{serverCert},
-		ClientCAs:    certPool,
-		RootCAs:      certPool,
-		ClientAuth:   config.ClientAuthType,
+		MinVersion:         tlsConfig.MinVersion,
+		MaxVersion:         tlsConfig.MaxVersion,
+		NextProtos:         tlsConfig.NextProtos,
+		ClientAuth:         tlsConfig.ClientAuth,
+		GetCertificate:     config.GetCertificate,
+		GetConfigForClient: config.GetConfigForClient,
 	}
 	return config.tlsConfig, nil
 }
+
+// newTLSConfig creates a new TLS configuration from the current key pair and certificates.
+func (config *certConfig) newTLSConfig() (*tls.Config, error) {
+	serverCerts, serverNames, err := config.newServerCertificates()
+	if err != nil {
+		return nil, err
+	}
+	rootCAs, err := config.newRootCAs()
+	if err != nil {
+		return nil, err
+	}
+	clientCAs, err := config.newClientCAs()
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxVersion != 0 && config.MaxVersion < config.MinVersion {
+		return nil, fmt.Errorf("maximum TLS version %s is less than the minimum version %s", tls.VersionName(config.MaxVersion), tls.VersionName(config.MinVersion))
+	}
+	if err := checkCipherSuites(config.CipherSuites); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ // nolint: exhaustruct
+		MinVersion:             config.MinVersion,
+		MaxVersion:             config.MaxVersion,
+		Certificates:           serverCerts,
+		ClientCAs:              clientCAs,
+		RootCAs:                rootCAs,
+		ClientAuth:             config.ClientAuthType,
+		SessionTicketsDisabled: config.SessionTicketsDisabled,
+	}
+	if 0 < len(config.CipherSuites) {
+		tlsConfig.CipherSuites = config.CipherSuites
+	}
+	if 0 < len(config.CurvePreferences) {
+		tlsConfig.CurvePreferences = config.CurvePreferences
+	}
+	if 0 < len(config.ALPNProtocols) {
+		tlsConfig.NextProtos = config.ALPNProtocols
+	}
+	if 0 < len(serverNames) {
+		tlsConfig.GetCertificate = newServerCertificateSelector(serverCerts, serverNames)
+	}
+	if 0 < len(config.TrustBundles) {
+		if err := config.setSPIFFEVerifier(tlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	if 0 < len(config.CRLs) || 0 < len(config.CRLFiles) {
+		if err := config.crlChecker.SetCRLs(config.CRLs, config.CRLFiles); err != nil {
+			return nil, err
+		}
+	}
+	if 0 < len(config.CRLs) || 0 < len(config.CRLFiles) || 0 < len(config.revCheckers) {
+		checker := config.RevocationChecker()
+		tlsConfig.VerifyPeerCertificate = func(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+			return checkVerifiedChains(checker, verifiedChains)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// newServerCertificates returns the server certificates with the default first, and the indexes of the certificates by the server names.
+func (config *certConfig) newServerCertificates() ([]tls.Certificate, map[string]int, error) {
+	certs := []tls.Certificate{}
+	if 0 < len(config.ServerCert) || 0 < len(config.ServerKey) || len(config.serverKeyPairs) == 0 {
+		cert, err := config.x509KeyPair(config.ServerCert, config.ServerKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+	}
+	names := map[string]int{}
+	for _, pair := range config.serverKeyPairs {
+		cert, err := config.x509KeyPair(pair.cert, pair.key)
+		if err != nil {
+			if pair.certFile != "" {
+				return nil, nil, fmt.Errorf("%s: %w", pair.certFile, err)
+			}
+			return nil, nil, err
+		}
+		certs = append(certs, cert)
+		serverNames := pair.serverNames
+		if len(serverNames) == 0 {
+			serverNames = cert.Leaf.DNSNames
+		}
+		for _, name := range serverNames {
+			name = normalizeServerName(name)
+			if _, ok := names[name]; !ok {
+				names[name] = len(certs) - 1
+			}
+		}
+	}
+	return certs, names, nil
+}
+
+// RootCertificates parses the root certificates and returns them.
+func (config *certConfig) RootCertificates() ([]*x509.Certificate, error) {
+	return parseCertificateList("root certificate", config.RootCerts, config.RootCertFiles)
+}
+
+// ClientCACertificates parses the CA certificates to verify client certificates and returns them.
+// If no client CA certificates are set, the root certificates are returned.
+func (config *certConfig) ClientCACertificates() ([]*x509.Certificate, error) {
+	if len(config.ClientCACerts) == 0 {
+		return config.RootCertificates()
+	}
+	return parseCertificateList("client CA certificate", config.ClientCACerts, config.ClientCACertFiles)
+}
+
+// newRootCAs returns the pool of the root certificates, which includes the system roots if enabled.
+func (config *certConfig) newRootCAs() (*x509.CertPool, error) {
+	certs, err := config.RootCertificates()
+	if err != nil {
+		return nil, err
+	}
+	return newCertPoolWithSystem(config.SystemRootCAs, certs)
+}
+
+// newClientCAs returns the pool of the client CA certificates, which includes the system roots if enabled.
+func (config *certConfig) newClientCAs() (*x509.CertPool, error) {
+	certs, err := config.ClientCACertificates()
+	if err != nil {
+		return nil, err
+	}
+	return newCertPoolWithSystem(config.SystemClientCAs, certs)
+}
+
+// CertificateExpiries returns the expiries of the certificates in the configuration.
+func (config *certConfig) CertificateExpiries() ([]CertificateExpiry, error) {
+	config.reloadMutex.Lock()
+	defer config.reloadMutex.Unlock()
+	now := time.Now()
+	expiries := []CertificateExpiry{}
+	serverChains := [][]byte{}
+	if 0 < len(config.ServerCert) {
+		serverChains = append(serverChains, config.ServerCert)
+	}
+	for _, pair := range config.serverKeyPairs {
+		serverChains = append(serverChains, pair.cert)
+	}
+	for n, data := range serverChains {
+		chain, err := ParseCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf("server certificate #%d: %w", n, err)
+		}
+		for i, cert := range chain {
+			role := RoleIntermediate
+			if i == 0 {
+				role = RoleServer
+			}
+			expiries = append(expiries, newCertificateExpiry(role, cert, now))
+		}
+	}
+	rootCerts, err := config.RootCertificates()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range rootCerts {
+		expiries = append(expiries, newCertificateExpiry(RoleRoot, cert, now))
+	}
+	if 0 < len(config.ClientCACerts) {
+		clientCACerts, err := config.ClientCACertificates()
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range clientCACerts {
+			expiries = append(expiries, newCertificateExpiry(RoleClientCA, cert, now))
+		}
+	}
+	for _, td := range slices.Sorted(maps.Keys(config.TrustBundles)) {
+		certs, err := parseCertificateList("trust bundle "+td, config.TrustBundles[td], nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			expiries = append(expiries, newCertificateExpiry(RoleTrustBundle, cert, now))
+		}
+	}
+	return expiries, nil
+}
+
+// Validate checks the configuration without building a TLS configuration.
+func (config *certConfig) Validate() error {
+	serverCerts, _, err := config.newServerCertificates()
+	if err != nil {
+		return err
+	}
+	rootCerts, err := config.RootCertificates()
+	if err != nil {
+		return err
+	}
+	if _, err := config.ClientCACertificates(); err != nil {
+		return err
+	}
+	for td, pems := range config.TrustBundles {
+		if _, err := parseCertificateList("trust bundle "+td, pems, nil); err != nil {
+			return err
+		}
+	}
+	opts := x509.VerifyOptions{ // nolint: exhaustruct
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if 0 < len(rootCerts) {
+		opts.Roots, err = newCertPoolWithSystem(config.SystemRootCAs, rootCerts)
+		if err != nil {
+			return err
+		}
+	}
+	for _, serverCert := range serverCerts {
+		for _, der := range serverCert.Certificate[1:] {
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return err
+			}
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := serverCert.Leaf.Verify(opts); err != nil {
+			return fmt.Errorf("server certificate %s: %w", serverCert.Leaf.Subject, err)
+		}
+	}
+	return nil
+}
+
+// x509KeyPair parses a key pair as tls.X509KeyPair, and decrypts the key if it is encrypted.
+func (config *certConfig) x509KeyPair(cert []byte, key []byte) (tls.Certificate, error) {
+	key, err := decryptPEMPrivateKey(key, config.passphraseProvider)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(cert, key)
+}
+
+// setSPIFFEVerifier adds the SPIFFE trust bundles to the client CAs and checks that each X.509-SVID is issued by the bundle of its trust domain.
+// The client certificates are still verified by crypto/tls, so the verified chains are kept in the connection state,
+// and client certificates without a SPIFFE ID are verified again against the client CAs without the trust bundles.
+func (config *certConfig) setSPIFFEVerifier(tlsConfig *tls.Config) error {
+	switch tlsConfig.ClientAuth {
+	case tls.RequireAndVerifyClientCert, tls.VerifyClientCertIfGiven:
+	case tls.NoClientCert:
+		return nil
+	default:
+		return fmt.Errorf("trust bundles require a client authentication type which verifies client certificates: %s", tlsConfig.ClientAuth)
+	}
+	clientCAs := tlsConfig.ClientCAs.Clone()
+	bundles := map[string][]*x509.Certificate{}
+	for td, pems := range config.TrustBundles {
+		for _, data := range pems {
+			certs, err := ParseCertificates(data)
+			if err != nil {
+				return fmt.Errorf("trust bundle %s: %w", td, err)
+			}
+			for _, cert := range certs {
+				clientCAs.AddCert(cert)
+			}
+			bundles[td] = append(bundles[td], certs...)
+		}
+	}
+	roots := tlsConfig.ClientCAs
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.VerifyConnection = verifySPIFFEConnection(bundles, roots)
+	return nil
+}
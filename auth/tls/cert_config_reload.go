@@ -0,0 +1,208 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"time"
+)
+
+// SetReloadInterval enables the reloading mode which polls the certificate and key files at the interval.
+// The files are polled and reloaded on the handshake path, which blocks the handshake while they are read.
+func (config *certConfig) SetReloadInterval(interval time.Duration) {
+	config.reloadMutex.Lock()
+	defer config.reloadMutex.Unlock()
+	config.reloadInterval = interval
+	config.tlsConfig = nil
+}
+
+// SetReloadErrorHandler sets the handler which is called when reloading the files fails.
+func (config *certConfig) SetReloadErrorHandler(handler func(error)) {
+	config.reloadMutex.Lock()
+	defer config.reloadMutex.Unlock()
+	config.reloadErrorHandler = handler
+}
+
+// Reload reloads the certificate and key files, and swaps the configuration.
+// In the reloading mode, the listeners created with the configuration returned by TLSConfig pick up the new configuration.
+// Otherwise, the new configuration is returned by the following TLSConfig calls.
+func (config *certConfig) Reload() error {
+	config.reloadMutex.Lock()
+	defer config.reloadMutex.Unlock()
+	return config.reload()
+}
+
+// GetCertificate returns the current server certificate.
+func (config *certConfig) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	tlsConfig, err := config.GetConfigForClient(hello)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil && tlsConfig.GetCertificate != nil && hello != nil {
+		cert, err := tlsConfig.GetCertificate(hello)
+		if cert != nil || err != nil {
+			return cert, err
+		}
+	}
+	if tlsConfig == nil || len(tlsConfig.Certificates) == 0 {
+		return nil, errors.New("no server certificate")
+	}
+	return &tlsConfig.Certificates[0], nil
+}
+
+// GetConfigForClient returns the current TLS configuration. In the reloading mode, the files are polled if the interval has elapsed.
+func (config *certConfig) GetConfigForClient(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+	if config.currentConfig.Load() == nil {
+		tlsConfig, err := config.TLSConfig()
+		if err != nil || config.currentConfig.Load() == nil {
+			return tlsConfig, err
+		}
+	}
+	config.pollFiles()
+	return config.currentConfig.Load(), nil
+}
+
+// reloadFiles returns the files which are watched in the reloading mode.
+func (config *certConfig) reloadFiles() []string {
+	files := []string{}
+	if config.ServerCertFile != "" {
+		files = append(files, config.ServerCertFile)
+	}
+	if config.ServerKeyFile != "" {
+		files = append(files, config.ServerKeyFile)
+	}
+	for _, pair := range config.serverKeyPairs {
+		if pair.certFile != "" {
+			files = append(files, pair.certFile, pair.keyFile)
+		}
+	}
+	files = append(files, config.RootCertFiles...)
+	return append(files, config.ClientCACertFiles...)
+}
+
+// pollFiles reloads the files if any of them has changed since the last successful reload.
+// The changes are committed only after the reload succeeds, so a failed reload, such as one which
+// reads a certificate before its key is replaced, is retried by the following polls.
+func (config *certConfig) pollFiles() {
+	config.reloadMutex.Lock()
+	defer config.reloadMutex.Unlock()
+	if config.watcher == nil || time.Since(config.reloadCheckedAt) < config.reloadInterval {
+		return
+	}
+	config.reloadCheckedAt = time.Now()
+	changed, err := config.watcher.Changed()
+	if err == nil && changed {
+		err = config.reload()
+	}
+	if err == nil {
+		config.watcher.Commit()
+	}
+	if err != nil && config.reloadErrorHandler != nil {
+		config.reloadErrorHandler(err)
+	}
+}
+
+// reload reads the files and swaps the configuration. The current key pair and certificates are kept on failure.
+func (config *certConfig) reload() error {
+	serverCert, serverKey, rootCerts, clientCACerts := config.ServerCert, config.ServerKey, config.RootCerts, config.ClientCACerts
+	pairs := make([]serverKeyPair, len(config.serverKeyPairs))
+	for n, pair := range config.serverKeyPairs {
+		pairs[n] = *pair
+	}
+	restore := func() {
+		config.ServerCert, config.ServerKey, config.RootCerts, config.ClientCACerts = serverCert, serverKey, rootCerts, clientCACerts
+		for n, pair := range pairs {
+			*config.serverKeyPairs[n] = pair
+		}
+	}
+	if err := config.readFiles(); err != nil {
+		restore()
+		return err
+	}
+	tlsConfig, err := config.newTLSConfig()
+	if err != nil {
+		restore()
+		return err
+	}
+	if config.currentConfig.Load() != nil {
+		config.currentConfig.Store(tlsConfig)
+	} else {
+		config.tlsConfig = tlsConfig
+		config.SetTLSEnabled(true)
+	}
+	return nil
+}
+
+// readFiles reads the certificate and key files into the configuration.
+func (config *certConfig) readFiles() error {
+	if config.ServerCertFile != "" {
+		cert, err := os.ReadFile(config.ServerCertFile)
+		if err != nil {
+			return err
+		}
+		config.ServerCert = cert
+	}
+	if config.ServerKeyFile != "" {
+		key, err := os.ReadFile(config.ServerKeyFile)
+		if err != nil {
+			return err
+		}
+		config.ServerKey = key
+	}
+	for _, pair := range config.serverKeyPairs {
+		if pair.certFile == "" {
+			continue
+		}
+		cert, err := os.ReadFile(pair.certFile)
+		if err != nil {
+			return err
+		}
+		key, err := os.ReadFile(pair.keyFile)
+		if err != nil {
+			return err
+		}
+		pair.cert, pair.key = cert, key
+	}
+	if 0 < len(config.RootCertFiles) {
+		certs, err := readFiles(config.RootCertFiles)
+		if err != nil {
+			return err
+		}
+		config.RootCerts = certs
+	}
+	if 0 < len(config.ClientCACertFiles) {
+		certs, err := readFiles(config.ClientCACertFiles)
+		if err != nil {
+			return err
+		}
+		config.ClientCACerts = certs
+	}
+	return nil
+}
+
+// readFiles reads the files.
+func readFiles(files []string) ([][]byte, error) {
+	data := make([][]byte, len(files))
+	for n, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data[n] = b
+	}
+	return data, nil
+}
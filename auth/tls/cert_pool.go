@@ -0,0 +1,97 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// ErrNoCertificates is returned when no certificates are found in the data.
+var ErrNoCertificates = errors.New("no certificates found")
+
+// ParseCertificates parses all certificates in the PEM or DER encoded data.
+// A PEM block which is not a certificate is skipped, and a malformed certificate is reported with the index of the PEM block.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, ErrNoCertificates
+		}
+		certs, err := x509.ParseCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf("neither PEM nor DER: %w", err)
+		}
+		return certs, nil
+	}
+	certs := []*x509.Certificate{}
+	for n := 0; block != nil; n++ {
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("PEM block %d: %w", n, err)
+			}
+			certs = append(certs, cert)
+		}
+		block, rest = pem.Decode(rest)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%w: no CERTIFICATE PEM blocks", ErrNoCertificates)
+	}
+	return certs, nil
+}
+
+// parseCertificateList parses the certificates of each data, and reports the file name or the index of the data which fails.
+func parseCertificateList(kind string, list [][]byte, files []string) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for n, data := range list {
+		parsed, err := ParseCertificates(data)
+		if err != nil {
+			if len(files) == len(list) {
+				return nil, fmt.Errorf("%s %s: %w", kind, files[n], err)
+			}
+			return nil, fmt.Errorf("%s #%d: %w", kind, n, err)
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
+
+// newCertPool returns a certificate pool which has the certificates.
+func newCertPool(certs []*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
+}
+
+// newCertPoolWithSystem returns a certificate pool which has the certificates, starting from the system roots if the system is true.
+func newCertPoolWithSystem(system bool, certs []*x509.Certificate) (*x509.CertPool, error) {
+	if !system {
+		return newCertPool(certs), nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
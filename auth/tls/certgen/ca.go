@@ -0,0 +1,102 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certgen
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+const (
+	defaultValidity   = 365 * 24 * time.Hour
+	defaultCAValidity = 10 * 365 * 24 * time.Hour
+)
+
+// CA represents an in-memory certificate authority which issues certificates.
+type CA struct {
+	cert *Certificate
+	// chain is the intermediate CA certificates which are presented with the issued certificates.
+	chain []*x509.Certificate
+}
+
+// NewCA generates a self-signed root CA. The common name is "Local CA" unless specified, and the validity period is ten years by default.
+func NewCA(opts ...Option) (*CA, error) {
+	o := newOptions(defaultCAValidity)
+	o.subject.CommonName = "Local CA"
+	cert, err := newCertificate(o, opts, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{cert: cert, chain: []*x509.Certificate{}}, nil
+}
+
+// NewIntermediateCA generates an intermediate CA issued by the CA.
+func (ca *CA) NewIntermediateCA(opts ...Option) (*CA, error) {
+	o := newOptions(defaultCAValidity)
+	o.subject.CommonName = "Local Intermediate CA"
+	cert, err := newCertificate(o, opts, true, ca)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{cert: cert, chain: append([]*x509.Certificate{cert.cert}, cert.chain...)}, nil
+}
+
+// NewServerCertificate generates a server certificate issued by the CA.
+// The extended key usage is server authentication, and the validity period is one year by default.
+// The common name defaults to the first DNS name.
+func (ca *CA) NewServerCertificate(opts ...Option) (*Certificate, error) {
+	o := newOptions(defaultValidity, x509.ExtKeyUsageServerAuth)
+	opts = append(opts, func(o *options) error {
+		if o.subject.CommonName == "" && 0 < len(o.dnsNames) {
+			o.subject.CommonName = o.dnsNames[0]
+		}
+		return nil
+	})
+	return newCertificate(o, opts, false, ca)
+}
+
+// NewClientCertificate generates a client certificate issued by the CA.
+// The extended key usage is client authentication, and the validity period is one year by default.
+func (ca *CA) NewClientCertificate(opts ...Option) (*Certificate, error) {
+	o := newOptions(defaultValidity, x509.ExtKeyUsageClientAuth)
+	return newCertificate(o, opts, false, ca)
+}
+
+// Certificate returns the CA certificate.
+func (ca *CA) Certificate() *x509.Certificate {
+	return ca.cert.Certificate()
+}
+
+// PrivateKey returns the private key of the CA, which signs the issued certificates and revocation lists.
+func (ca *CA) PrivateKey() crypto.Signer {
+	return ca.cert.PrivateKey()
+}
+
+// CertificatePEM returns the PEM encoded CA certificate followed by its issuing intermediate CA certificates.
+func (ca *CA) CertificatePEM() []byte {
+	return ca.cert.CertificatePEM()
+}
+
+// KeyPEM returns the PEM encoded PKCS#8 private key of the CA.
+func (ca *CA) KeyPEM() []byte {
+	return ca.cert.KeyPEM()
+}
+
+// TLSCertificate returns the CA certificate and private key as tls.Certificate.
+func (ca *CA) TLSCertificate() tls.Certificate {
+	return ca.cert.TLSCertificate()
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certgen generates in-memory CAs and certificates to bootstrap TLS and mutual TLS without openssl.
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// Certificate represents a generated certificate and its private key.
+type Certificate struct {
+	cert  *x509.Certificate
+	key   crypto.Signer
+	chain []*x509.Certificate
+	// keyPEM is the PEM encoded private key, which is encoded when the certificate is generated to report the error.
+	keyPEM []byte
+}
+
+// Certificate returns the certificate.
+func (c *Certificate) Certificate() *x509.Certificate {
+	return c.cert
+}
+
+// PrivateKey returns the private key.
+func (c *Certificate) PrivateKey() crypto.Signer {
+	return c.key
+}
+
+// CertificatePEM returns the PEM encoded certificate followed by the intermediate CA certificates.
+func (c *Certificate) CertificatePEM() []byte {
+	data := encodeCertificate(c.cert)
+	for _, cert := range c.chain {
+		data = append(data, encodeCertificate(cert)...)
+	}
+	return data
+}
+
+// KeyPEM returns the PEM encoded PKCS#8 private key.
+func (c *Certificate) KeyPEM() []byte {
+	return c.keyPEM
+}
+
+// TLSCertificate returns the certificate, the intermediate CA certificates and the private key as tls.Certificate.
+func (c *Certificate) TLSCertificate() tls.Certificate {
+	certs := [][]byte{c.cert.Raw}
+	for _, cert := range c.chain {
+		certs = append(certs, cert.Raw)
+	}
+	return tls.Certificate{ // nolint: exhaustruct
+		Certificate: certs,
+		PrivateKey:  c.key,
+		Leaf:        c.cert,
+	}
+}
+
+// WriteFiles writes the PEM encoded certificate and private key to the files. The key file is readable only by the owner.
+func (c *Certificate) WriteFiles(certFile string, keyFile string) error {
+	if err := os.WriteFile(certFile, c.CertificatePEM(), 0o644); err != nil { // nolint: gosec
+		return err
+	}
+	return os.WriteFile(keyFile, c.KeyPEM(), 0o600)
+}
+
+// NewSelfSignedCertificate generates a self-signed server certificate.
+// The extended key usages are server and client authentication by default.
+func NewSelfSignedCertificate(opts ...Option) (*Certificate, error) {
+	o := newOptions(defaultValidity, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
+	return newCertificate(o, opts, false, nil)
+}
+
+func encodeCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}) // nolint: exhaustruct
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil // nolint: exhaustruct
+}
+
+func generateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case ECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case RSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case RSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("unknown key type: %d", keyType)
+}
+
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// newCertificate generates a certificate from the options. If the issuer is nil, the certificate is self-signed.
+func newCertificate(o *options, opts []Option, isCA bool, issuer *CA) (*Certificate, error) {
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	key, err := generateKey(o.keyType)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{ // nolint: exhaustruct
+		SerialNumber:   serial,
+		Subject:        o.subject,
+		NotBefore:      o.notBefore,
+		NotAfter:       o.notAfter,
+		DNSNames:       o.dnsNames,
+		IPAddresses:    o.ipAddresses,
+		EmailAddresses: o.emails,
+		URIs:           o.uris,
+		ExtKeyUsage:    o.extKeyUsages,
+		Policies:       o.policies,
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	}
+
+	parent, parentKey := tmpl, key
+	chain := []*x509.Certificate{}
+	if issuer != nil {
+		parent, parentKey = issuer.cert.cert, issuer.cert.key
+		chain = issuer.chain
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{cert: cert, key: key, chain: chain, keyPEM: keyPEM}, nil
+}
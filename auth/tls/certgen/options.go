@@ -0,0 +1,175 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certgen
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"time"
+)
+
+// KeyType represents a type of private keys.
+type KeyType int
+
+const (
+	// ECDSAP256 is an ECDSA key on the P-256 curve, which is the default.
+	ECDSAP256 KeyType = iota
+	// ECDSAP384 is an ECDSA key on the P-384 curve.
+	ECDSAP384
+	// RSA2048 is a 2048-bit RSA key.
+	RSA2048
+	// RSA4096 is a 4096-bit RSA key.
+	RSA4096
+	// Ed25519 is an Ed25519 key.
+	Ed25519
+)
+
+// Option is a function to set the certificate options.
+type Option func(*options) error
+
+// options represents the certificate options.
+type options struct {
+	subject      pkix.Name
+	dnsNames     []string
+	ipAddresses  []net.IP
+	emails       []string
+	uris         []*url.URL
+	extKeyUsages []x509.ExtKeyUsage
+	policies     []x509.OID
+	notBefore    time.Time
+	notAfter     time.Time
+	keyType      KeyType
+}
+
+func newOptions(validity time.Duration, usages ...x509.ExtKeyUsage) *options {
+	now := time.Now()
+	return &options{
+		subject:      pkix.Name{}, // nolint: exhaustruct
+		dnsNames:     []string{},
+		ipAddresses:  []net.IP{},
+		emails:       []string{},
+		uris:         []*url.URL{},
+		extKeyUsages: usages,
+		policies:     []x509.OID{},
+		notBefore:    now.Add(-time.Minute),
+		notAfter:     now.Add(validity),
+		keyType:      ECDSAP256,
+	}
+}
+
+// WithCommonName sets the common name of the subject.
+func WithCommonName(cn string) Option {
+	return func(opts *options) error {
+		opts.subject.CommonName = cn
+		return nil
+	}
+}
+
+// WithSubject sets the subject. The common name set by WithCommonName is overwritten.
+func WithSubject(subject pkix.Name) Option {
+	return func(opts *options) error {
+		opts.subject = subject
+		return nil
+	}
+}
+
+// WithDNSNames adds the DNS names to the subject alternative names. The IP addresses in the names are added as IP addresses.
+func WithDNSNames(names ...string) Option {
+	return func(opts *options) error {
+		for _, name := range names {
+			if ip := net.ParseIP(name); ip != nil {
+				opts.ipAddresses = append(opts.ipAddresses, ip)
+				continue
+			}
+			opts.dnsNames = append(opts.dnsNames, name)
+		}
+		return nil
+	}
+}
+
+// WithIPAddresses adds the IP addresses to the subject alternative names.
+func WithIPAddresses(ips ...net.IP) Option {
+	return func(opts *options) error {
+		opts.ipAddresses = append(opts.ipAddresses, ips...)
+		return nil
+	}
+}
+
+// WithEmailAddresses adds the email addresses to the subject alternative names.
+func WithEmailAddresses(emails ...string) Option {
+	return func(opts *options) error {
+		opts.emails = append(opts.emails, emails...)
+		return nil
+	}
+}
+
+// WithURIs adds the URIs such as SPIFFE IDs to the subject alternative names.
+func WithURIs(uris ...string) Option {
+	return func(opts *options) error {
+		for _, s := range uris {
+			u, err := url.Parse(s)
+			if err != nil {
+				return err
+			}
+			opts.uris = append(opts.uris, u)
+		}
+		return nil
+	}
+}
+
+// WithExtKeyUsages sets the extended key usages instead of the default usages.
+func WithExtKeyUsages(usages ...x509.ExtKeyUsage) Option {
+	return func(opts *options) error {
+		opts.extKeyUsages = usages
+		return nil
+	}
+}
+
+// WithPolicies sets the certificate policy OIDs.
+func WithPolicies(oids ...x509.OID) Option {
+	return func(opts *options) error {
+		opts.policies = oids
+		return nil
+	}
+}
+
+// WithValidity sets the validity period.
+func WithValidity(notBefore time.Time, notAfter time.Time) Option {
+	return func(opts *options) error {
+		opts.notBefore = notBefore
+		opts.notAfter = notAfter
+		return nil
+	}
+}
+
+// WithValidityPeriod sets the validity period from now.
+func WithValidityPeriod(d time.Duration) Option {
+	return func(opts *options) error {
+		now := time.Now()
+		opts.notBefore = now.Add(-time.Minute)
+		opts.notAfter = now.Add(d)
+		return nil
+	}
+}
+
+// WithKeyType sets the type of the private key.
+func WithKeyType(keyType KeyType) Option {
+	return func(opts *options) error {
+		opts.keyType = keyType
+		return nil
+	}
+}
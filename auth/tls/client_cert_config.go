@@ -0,0 +1,54 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+)
+
+// ClientCertConfig represents a client-side TLS configuration interface, which is the mirror image of CertConfig.
+type ClientCertConfig interface {
+	// SetClientCert sets a SSL client certificate, optionally followed by the chain.
+	SetClientCert(cert []byte)
+	// SetClientKey sets a SSL client key.
+	// An encrypted PKCS#8 key is decrypted by the passphrase provider set by SetClientKeyPassphraseProvider.
+	SetClientKey(key []byte)
+	// SetClientCertFile loads a SSL client certificate file and sets it.
+	SetClientCertFile(file string) error
+	// SetClientKeyFile loads a SSL client key file and sets it.
+	SetClientKeyFile(file string) error
+	// SetClientKeyPassphraseProvider sets the provider of the passphrase to decrypt the encrypted PKCS#8 client key.
+	SetClientKeyPassphraseProvider(provider PassphraseProvider)
+	// SetClientPKCS12 sets the SSL client key, certificate and chain from a PKCS#12 bundle which is decrypted by the passphrase of the provider.
+	SetClientPKCS12(data []byte, provider PassphraseProvider) error
+	// SetClientPKCS12File loads a PKCS#12 bundle file and sets it as SetClientPKCS12.
+	SetClientPKCS12File(file string, provider PassphraseProvider) error
+	// SetRootCerts sets SSL root certificates to verify the server certificates. If no root certificates are set, the system roots are used.
+	SetRootCerts(certs ...[]byte)
+	// SetRootCertFiles loads SSL root certificate files and sets them.
+	// An error is returned if any file has no valid PEM or DER encoded certificates.
+	SetRootCertFiles(files ...string) error
+	// SetSystemRootCAs includes the system root certificates in addition to the root certificates if true.
+	SetSystemRootCAs(enabled bool)
+	// SetServerName sets the expected server name, which is sent by SNI and verified against the server certificate.
+	SetServerName(name string)
+	// SetPinnedCertificates sets the fingerprints of the server certificates.
+	// If any fingerprint is set, the server certificate must match one of the certificate or public key fingerprints in addition to the chain verification.
+	SetPinnedCertificates(fps ...Fingerprint)
+	// SetPinnedPublicKeys sets the fingerprints of the SubjectPublicKeyInfo of the server certificates.
+	SetPinnedPublicKeys(fps ...Fingerprint)
+	// TLSConfig returns a client TLS configuration from the configuration.
+	TLSConfig() (*tls.Config, error)
+}
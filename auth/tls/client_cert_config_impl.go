@@ -0,0 +1,218 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ErrPinnedCertificate is returned when the server certificate does not match the pinned fingerprints.
+var ErrPinnedCertificate = errors.New("server certificate does not match the pinned fingerprints")
+
+// clientCertConfig represents a client-side TLS configuration.
+type clientCertConfig struct {
+	ClientCert         []byte
+	ClientKey          []byte
+	RootCerts          [][]byte
+	RootCertFiles      []string
+	SystemRootCAs      bool
+	ServerName         string
+	CertFingerprints   []Fingerprint
+	KeyFingerprints    []Fingerprint
+	passphraseProvider PassphraseProvider
+	tlsConfig          *tls.Config
+}
+
+// NewClientCertConfig returns a new client-side TLS configuration.
+func NewClientCertConfig() ClientCertConfig {
+	return &clientCertConfig{
+		ClientCert:         []byte{},
+		ClientKey:          []byte{},
+		RootCerts:          [][]byte{},
+		RootCertFiles:      []string{},
+		SystemRootCAs:      false,
+		ServerName:         "",
+		CertFingerprints:   []Fingerprint{},
+		KeyFingerprints:    []Fingerprint{},
+		passphraseProvider: nil,
+		tlsConfig:          nil,
+	}
+}
+
+// SetClientCert sets a SSL client certificate.
+func (config *clientCertConfig) SetClientCert(cert []byte) {
+	config.ClientCert = cert
+	config.tlsConfig = nil
+}
+
+// SetClientKey sets a SSL client key.
+func (config *clientCertConfig) SetClientKey(key []byte) {
+	config.ClientKey = key
+	config.tlsConfig = nil
+}
+
+// SetClientCertFile loads a SSL client certificate file and sets it.
+func (config *clientCertConfig) SetClientCertFile(file string) error {
+	cert, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	config.SetClientCert(cert)
+	return nil
+}
+
+// SetClientKeyFile loads a SSL client key file and sets it.
+func (config *clientCertConfig) SetClientKeyFile(file string) error {
+	key, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	config.SetClientKey(key)
+	return nil
+}
+
+// SetClientKeyPassphraseProvider sets the provider of the passphrase to decrypt the encrypted PKCS#8 client key.
+func (config *clientCertConfig) SetClientKeyPassphraseProvider(provider PassphraseProvider) {
+	config.passphraseProvider = provider
+	config.tlsConfig = nil
+}
+
+// SetClientPKCS12 sets the SSL client key, certificate and chain from a PKCS#12 bundle.
+func (config *clientCertConfig) SetClientPKCS12(data []byte, provider PassphraseProvider) error {
+	cert, key, err := decodePKCS12(data, provider)
+	if err != nil {
+		return err
+	}
+	config.SetClientCert(cert)
+	config.SetClientKey(key)
+	return nil
+}
+
+// SetClientPKCS12File loads a PKCS#12 bundle file and sets it.
+func (config *clientCertConfig) SetClientPKCS12File(file string, provider PassphraseProvider) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := config.SetClientPKCS12(data, provider); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// SetRootCerts sets SSL root certificates to verify the server certificates.
+func (config *clientCertConfig) SetRootCerts(certs ...[]byte) {
+	config.RootCerts = certs
+	config.RootCertFiles = []string{}
+	config.tlsConfig = nil
+}
+
+// SetRootCertFiles loads SSL root certificate files and sets them.
+func (config *clientCertConfig) SetRootCertFiles(files ...string) error {
+	certs := make([][]byte, len(files))
+	for n, file := range files {
+		cert, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := ParseCertificates(cert); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		certs[n] = cert
+	}
+	config.SetRootCerts(certs...)
+	config.RootCertFiles = files
+	return nil
+}
+
+// SetSystemRootCAs includes the system root certificates in addition to the root certificates if true.
+func (config *clientCertConfig) SetSystemRootCAs(enabled bool) {
+	config.SystemRootCAs = enabled
+	config.tlsConfig = nil
+}
+
+// SetServerName sets the expected server name.
+func (config *clientCertConfig) SetServerName(name string) {
+	config.ServerName = name
+	config.tlsConfig = nil
+}
+
+// SetPinnedCertificates sets the fingerprints of the server certificates.
+func (config *clientCertConfig) SetPinnedCertificates(fps ...Fingerprint) {
+	config.CertFingerprints = fps
+	config.tlsConfig = nil
+}
+
+// SetPinnedPublicKeys sets the fingerprints of the SubjectPublicKeyInfo of the server certificates.
+func (config *clientCertConfig) SetPinnedPublicKeys(fps ...Fingerprint) {
+	config.KeyFingerprints = fps
+	config.tlsConfig = nil
+}
+
+// TLSConfig returns a client TLS configuration from the configuration.
+func (config *clientCertConfig) TLSConfig() (*tls.Config, error) {
+	if config.tlsConfig != nil {
+		return config.tlsConfig, nil
+	}
+	tlsConfig := &tls.Config{ // nolint: exhaustruct
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ServerName,
+	}
+	if 0 < len(config.ClientCert) || 0 < len(config.ClientKey) {
+		key, err := decryptPEMPrivateKey(config.ClientKey, config.passphraseProvider)
+		if err != nil {
+			return nil, err
+		}
+		clientCert, err := tls.X509KeyPair(config.ClientCert, key)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	if 0 < len(config.RootCerts) {
+		rootCerts, err := parseCertificateList("root certificate", config.RootCerts, config.RootCertFiles)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs, err = newCertPoolWithSystem(config.SystemRootCAs, rootCerts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if 0 < len(config.CertFingerprints) || 0 < len(config.KeyFingerprints) {
+		tlsConfig.VerifyConnection = config.verifyPinnedConnection
+	}
+	config.tlsConfig = tlsConfig
+	return config.tlsConfig, nil
+}
+
+// verifyPinnedConnection checks that the server certificate matches the pinned fingerprints after the chain verification.
+func (config *clientCertConfig) verifyPinnedConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return ErrPinnedCertificate
+	}
+	leaf := state.PeerCertificates[0]
+	if slices.Contains(config.CertFingerprints, CertificateFingerprint(leaf)) {
+		return nil
+	}
+	if slices.Contains(config.KeyFingerprints, PublicKeyFingerprint(leaf)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrPinnedCertificate, leaf.Subject)
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// crl represents a parsed certificate revocation list.
+type crl struct {
+	list    *x509.RevocationList
+	revoked map[string]time.Time
+}
+
+// crlChecker represents a revocation checker using certificate revocation lists.
+type crlChecker struct {
+	sync.Mutex
+	data         [][]byte
+	files        []string
+	crls         []*crl
+	interval     time.Duration
+	loadedAt     time.Time
+	errorHandler func(error)
+}
+
+func newCRLChecker() *crlChecker {
+	return &crlChecker{
+		Mutex:        sync.Mutex{},
+		data:         [][]byte{},
+		files:        []string{},
+		crls:         []*crl{},
+		interval:     0,
+		loadedAt:     time.Time{},
+		errorHandler: nil,
+	}
+}
+
+// parseCRLs parses the specified PEM or DER encoded certificate revocation lists.
+func parseCRLs(data []byte) ([]*crl, error) {
+	ders := [][]byte{}
+	if block, _ := pem.Decode(data); block == nil {
+		ders = append(ders, data)
+	} else {
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "X509 CRL" {
+				continue
+			}
+			ders = append(ders, block.Bytes)
+		}
+	}
+	crls := make([]*crl, 0, len(ders))
+	for _, der := range ders {
+		list, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, err
+		}
+		revoked := map[string]time.Time{}
+		for _, entry := range list.RevokedCertificateEntries {
+			revoked[entry.SerialNumber.String()] = entry.RevocationTime
+		}
+		crls = append(crls, &crl{list: list, revoked: revoked})
+	}
+	if len(crls) == 0 {
+		return nil, errors.New("no certificate revocation lists found")
+	}
+	return crls, nil
+}
+
+// SetCRLs sets the PEM or DER encoded certificate revocation lists.
+func (checker *crlChecker) SetCRLs(data [][]byte, files []string) error {
+	checker.Lock()
+	defer checker.Unlock()
+	checker.data = data
+	checker.files = files
+	return checker.load()
+}
+
+// SetReloadInterval sets the interval to reload the certificate revocation list files.
+func (checker *crlChecker) SetReloadInterval(interval time.Duration) {
+	checker.Lock()
+	defer checker.Unlock()
+	checker.interval = interval
+}
+
+// SetErrorHandler sets the handler which is called when reloading the certificate revocation list files fails.
+func (checker *crlChecker) SetErrorHandler(handler func(error)) {
+	checker.Lock()
+	defer checker.Unlock()
+	checker.errorHandler = handler
+}
+
+// load parses the data and files. The current lists are kept if any of them is invalid.
+func (checker *crlChecker) load() error {
+	crls := []*crl{}
+	for _, data := range checker.data {
+		parsed, err := parseCRLs(data)
+		if err != nil {
+			return err
+		}
+		crls = append(crls, parsed...)
+	}
+	for _, file := range checker.files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseCRLs(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		crls = append(crls, parsed...)
+	}
+	checker.crls = crls
+	checker.loadedAt = time.Now()
+	return nil
+}
+
+// reloadIfExpired reloads the files if the reload interval has elapsed since the last load.
+func (checker *crlChecker) reloadIfExpired() {
+	if checker.interval <= 0 || len(checker.files) == 0 || time.Since(checker.loadedAt) < checker.interval {
+		return
+	}
+	if err := checker.load(); err != nil {
+		// Keep the current lists and retry at the next interval.
+		checker.loadedAt = time.Now()
+		if checker.errorHandler != nil {
+			checker.errorHandler(err)
+		}
+	}
+}
+
+// CheckRevocation checks the revocation status of the certificates in the verified chain.
+// Each certificate is checked against the lists issued by the next certificate in the chain,
+// and a list whose signature is not verified by the issuer is ignored.
+func (checker *crlChecker) CheckRevocation(chain []*x509.Certificate) error {
+	checker.Lock()
+	defer checker.Unlock()
+	checker.reloadIfExpired()
+	for n := 0; n < len(chain)-1; n++ {
+		cert, issuer := chain[n], chain[n+1]
+		for _, crl := range checker.crls {
+			if !bytes.Equal(crl.list.RawIssuer, issuer.RawSubject) {
+				continue
+			}
+			revokedAt, ok := crl.revoked[cert.SerialNumber.String()]
+			if !ok {
+				continue
+			}
+			if err := crl.list.CheckSignatureFrom(issuer); err != nil {
+				continue
+			}
+			return fmt.Errorf("%w: serial %s of %s at %s", ErrRevoked, cert.SerialNumber, cert.Subject, revokedAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDistinguishedName is returned when a distinguished name is invalid.
+var ErrInvalidDistinguishedName = errors.New("invalid distinguished name")
+
+var dnAttributeTypes = map[string]asn1.ObjectIdentifier{
+	"CN":           {2, 5, 4, 3},
+	"SERIALNUMBER": {2, 5, 4, 5},
+	"C":            {2, 5, 4, 6},
+	"L":            {2, 5, 4, 7},
+	"ST":           {2, 5, 4, 8},
+	"STREET":       {2, 5, 4, 9},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"TITLE":        {2, 5, 4, 12},
+	"POSTALCODE":   {2, 5, 4, 17},
+	"UID":          {0, 9, 2342, 19200300, 100, 1, 1},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+	"EMAILADDRESS": {1, 2, 840, 113549, 1, 9, 1},
+}
+
+// ParseAttributeType parses the specified attribute type such as "CN", "serialNumber" or "2.5.4.3".
+// The short names are case-insensitive.
+func ParseAttributeType(name string) (asn1.ObjectIdentifier, error) {
+	name = strings.TrimSpace(name)
+	if oid, ok := dnAttributeTypes[strings.ToUpper(name)]; ok {
+		return oid, nil
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("%w: unknown attribute type: %s", ErrInvalidDistinguishedName, name)
+	}
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for n, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("%w: unknown attribute type: %s", ErrInvalidDistinguishedName, name)
+		}
+		oid[n] = v
+	}
+	return oid, nil
+}
+
+// ParseDistinguishedName parses the specified distinguished name in RFC 4514 string form such as "CN=app,OU=payments,O=Corp".
+// The returned sequence is in ASN.1 order, which is the reverse of the string form, as pkix.Name.ToRDNSequence.
+// Values in hexadecimal BER form such as "CN=#0403..." are not supported.
+func ParseDistinguishedName(dn string) (pkix.RDNSequence, error) {
+	rdns := pkix.RDNSequence{}
+	if len(strings.TrimSpace(dn)) == 0 {
+		return rdns, nil
+	}
+	rdn := pkix.RelativeDistinguishedNameSET{}
+	attrType := ""
+	var value strings.Builder
+	inValue := false
+	trailing := 0 // number of unescaped trailing spaces in the value
+
+	addAttribute := func() error {
+		if !inValue {
+			return fmt.Errorf("%w: missing '=': %s", ErrInvalidDistinguishedName, dn)
+		}
+		oid, err := ParseAttributeType(attrType)
+		if err != nil {
+			return err
+		}
+		v := value.String()
+		v = v[:len(v)-trailing]
+		if strings.HasPrefix(v, "#") {
+			return fmt.Errorf("%w: hexadecimal value is not supported: %s", ErrInvalidDistinguishedName, dn)
+		}
+		rdn = append(rdn, pkix.AttributeTypeAndValue{Type: oid, Value: v})
+		attrType = ""
+		value.Reset()
+		inValue = false
+		trailing = 0
+		return nil
+	}
+
+	for n := 0; n < len(dn); n++ {
+		c := dn[n]
+		if !inValue {
+			switch c {
+			case '=':
+				inValue = true
+			case ',', ';', '+':
+				return nil, fmt.Errorf("%w: missing '=': %s", ErrInvalidDistinguishedName, dn)
+			default:
+				attrType += string(c)
+			}
+			continue
+		}
+		switch c {
+		case '\\':
+			if len(dn) <= n+1 {
+				return nil, fmt.Errorf("%w: trailing '\\': %s", ErrInvalidDistinguishedName, dn)
+			}
+			if n+2 < len(dn) && isHexDigit(dn[n+1]) && isHexDigit(dn[n+2]) {
+				b, _ := hex.DecodeString(dn[n+1 : n+3])
+				value.Write(b)
+				n += 2
+			} else {
+				value.WriteByte(dn[n+1])
+				n++
+			}
+			trailing = 0
+		case ',', ';', '+':
+			if err := addAttribute(); err != nil {
+				return nil, err
+			}
+			if c != '+' {
+				rdns = append(rdns, rdn)
+				rdn = pkix.RelativeDistinguishedNameSET{}
+			}
+		case ' ':
+			if value.Len() == 0 {
+				continue
+			}
+			value.WriteByte(c)
+			trailing++
+		default:
+			value.WriteByte(c)
+			trailing = 0
+		}
+	}
+	if err := addAttribute(); err != nil {
+		return nil, err
+	}
+	rdns = append(rdns, rdn)
+
+	// Reverse the sequence into ASN.1 order.
+	for i, j := 0, len(rdns)-1; i < j; i, j = i+1, j-1 {
+		rdns[i], rdns[j] = rdns[j], rdns[i]
+	}
+	return rdns, nil
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
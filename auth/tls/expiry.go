@@ -0,0 +1,252 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CertificateRole represents the role of a certificate in the configuration.
+type CertificateRole string
+
+const (
+	// RoleServer is a server certificate.
+	RoleServer CertificateRole = "server"
+	// RoleIntermediate is a certificate in the chain of a server certificate.
+	RoleIntermediate CertificateRole = "intermediate"
+	// RoleRoot is a root certificate.
+	RoleRoot CertificateRole = "root"
+	// RoleClientCA is a CA certificate to verify client certificates.
+	RoleClientCA CertificateRole = "client CA"
+	// RoleTrustBundle is a certificate in a SPIFFE trust bundle.
+	RoleTrustBundle CertificateRole = "trust bundle"
+)
+
+// CertificateExpiry represents the expiry of a certificate.
+type CertificateExpiry struct {
+	// Role is the role of the certificate.
+	Role CertificateRole
+	// Certificate is the certificate.
+	Certificate *x509.Certificate
+	// NotAfter is the expiry time of the certificate.
+	NotAfter time.Time
+	// Remaining is the duration until the expiry when reported, which is negative if the certificate has expired.
+	Remaining time.Duration
+}
+
+// Expired returns true if the certificate has expired.
+func (e CertificateExpiry) Expired() bool {
+	return e.Remaining <= 0
+}
+
+func newCertificateExpiry(role CertificateRole, cert *x509.Certificate, now time.Time) CertificateExpiry {
+	return CertificateExpiry{
+		Role:        role,
+		Certificate: cert,
+		NotAfter:    cert.NotAfter,
+		Remaining:   cert.NotAfter.Sub(now),
+	}
+}
+
+// ExpiryEvent represents a notification that a certificate has crossed an expiry threshold.
+type ExpiryEvent struct {
+	CertificateExpiry
+	// Threshold is the crossed threshold, which is zero if the certificate has expired.
+	Threshold time.Duration
+}
+
+// ExpiryMonitor represents a monitor which checks the certificates of the configuration periodically,
+// and notifies the handler once per certificate when it crosses each threshold before the expiry.
+type ExpiryMonitor interface {
+	// Check checks the certificates now and notifies the handler of the crossed thresholds.
+	Check() error
+	// Start starts checking the certificates periodically. The certificates are checked immediately.
+	// An error is returned if the check interval is not positive.
+	Start() error
+	// Stop stops checking the certificates.
+	Stop() error
+}
+
+// ExpiryMonitorOption is a function to set the expiry monitor options.
+type ExpiryMonitorOption func(*expiryMonitor)
+
+// WithExpiryThresholds sets the thresholds before the expiry. The default thresholds are 30 days, 7 days and 1 day.
+func WithExpiryThresholds(thresholds ...time.Duration) ExpiryMonitorOption {
+	return func(m *expiryMonitor) {
+		m.thresholds = slices.Clone(thresholds)
+	}
+}
+
+// WithExpiryHandler sets the handler which is called when a certificate crosses a threshold or expires.
+func WithExpiryHandler(handler func(ExpiryEvent)) ExpiryMonitorOption {
+	return func(m *expiryMonitor) {
+		m.handler = handler
+	}
+}
+
+// WithExpiryErrorHandler sets the handler which is called when the certificates cannot be loaded by a periodic check.
+func WithExpiryErrorHandler(handler func(error)) ExpiryMonitorOption {
+	return func(m *expiryMonitor) {
+		m.errorHandler = handler
+	}
+}
+
+// WithExpiryCheckInterval sets the interval of the periodic checks. The default interval is one hour, and the interval must be positive.
+func WithExpiryCheckInterval(interval time.Duration) ExpiryMonitorOption {
+	return func(m *expiryMonitor) {
+		m.interval = interval
+	}
+}
+
+// expiryMonitor represents a certificate expiry monitor.
+// The embedded mutex guards the checks, and runMutex guards starting and stopping the periodic checks,
+// which waits for a running check to finish.
+type expiryMonitor struct {
+	sync.Mutex
+	config       CertConfig
+	thresholds   []time.Duration
+	handler      func(ExpiryEvent)
+	errorHandler func(error)
+	interval     time.Duration
+	notified     map[Fingerprint]time.Duration
+	runMutex     sync.Mutex
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// NewExpiryMonitor returns a new expiry monitor of the certificates reported by CertConfig::CertificateExpiries.
+func NewExpiryMonitor(config CertConfig, opts ...ExpiryMonitorOption) ExpiryMonitor {
+	m := &expiryMonitor{
+		Mutex:        sync.Mutex{},
+		config:       config,
+		thresholds:   []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour},
+		handler:      nil,
+		errorHandler: nil,
+		interval:     time.Hour,
+		notified:     map[Fingerprint]time.Duration{},
+		runMutex:     sync.Mutex{},
+		stop:         nil,
+		done:         nil,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	slices.Sort(m.thresholds)
+	return m
+}
+
+// crossedThreshold returns the smallest threshold which the remaining duration has crossed.
+func (m *expiryMonitor) crossedThreshold(remaining time.Duration) (time.Duration, bool) {
+	if remaining <= 0 {
+		return 0, true
+	}
+	for _, threshold := range m.thresholds {
+		if remaining <= threshold {
+			return threshold, true
+		}
+	}
+	return 0, false
+}
+
+// Check checks the certificates now and notifies the handler of the crossed thresholds.
+// The notifications of the certificates which are no longer reported, such as rotated ones, are forgotten.
+func (m *expiryMonitor) Check() error {
+	m.Lock()
+	defer m.Unlock()
+	expiries, err := m.config.CertificateExpiries()
+	if err != nil {
+		return err
+	}
+	reported := map[Fingerprint]bool{}
+	for _, expiry := range expiries {
+		reported[CertificateFingerprint(expiry.Certificate)] = true
+	}
+	for fp := range m.notified {
+		if !reported[fp] {
+			delete(m.notified, fp)
+		}
+	}
+	for _, expiry := range expiries {
+		threshold, ok := m.crossedThreshold(expiry.Remaining)
+		if !ok {
+			continue
+		}
+		fp := CertificateFingerprint(expiry.Certificate)
+		if last, ok := m.notified[fp]; ok && last <= threshold {
+			continue
+		}
+		m.notified[fp] = threshold
+		if m.handler != nil {
+			m.handler(ExpiryEvent{CertificateExpiry: expiry, Threshold: threshold})
+		}
+	}
+	return nil
+}
+
+// Start starts checking the certificates periodically.
+func (m *expiryMonitor) Start() error {
+	if m.interval <= 0 {
+		return fmt.Errorf("expiry check interval must be positive: %s", m.interval)
+	}
+	m.runMutex.Lock()
+	defer m.runMutex.Unlock()
+	m.stopRun()
+	if err := m.Check(); err != nil {
+		return err
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.run(m.stop, m.done)
+	return nil
+}
+
+func (m *expiryMonitor) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := m.Check(); err != nil && m.errorHandler != nil {
+				m.errorHandler(err)
+			}
+		}
+	}
+}
+
+// Stop stops checking the certificates.
+func (m *expiryMonitor) Stop() error {
+	m.runMutex.Lock()
+	defer m.runMutex.Unlock()
+	m.stopRun()
+	return nil
+}
+
+// stopRun stops the periodic checks and waits for them to finish. The caller must hold runMutex.
+func (m *expiryMonitor) stopRun() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+	m.stop = nil
+	m.done = nil
+}
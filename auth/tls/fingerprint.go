@@ -0,0 +1,103 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrInvalidFingerprint is returned when a fingerprint is invalid.
+var ErrInvalidFingerprint = errors.New("invalid fingerprint")
+
+// Fingerprint represents a SHA-256 fingerprint.
+type Fingerprint [sha256.Size]byte
+
+// ParseFingerprint parses the specified SHA-256 fingerprint in hex, with or without colon separators,
+// or in base64 with an optional "sha256/" prefix as HTTP public key pinning.
+func ParseFingerprint(s string) (Fingerprint, error) {
+	var fp Fingerprint
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(strings.ReplaceAll(s, ":", "")); err == nil {
+		if len(b) != len(fp) {
+			return fp, fmt.Errorf("%w: %d bytes: %s", ErrInvalidFingerprint, len(b), s)
+		}
+		copy(fp[:], b)
+		return fp, nil
+	}
+	b64 := strings.TrimPrefix(s, "sha256/")
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		b, err := enc.DecodeString(b64)
+		if err != nil {
+			continue
+		}
+		if len(b) != len(fp) {
+			return fp, fmt.Errorf("%w: %d bytes: %s", ErrInvalidFingerprint, len(b), s)
+		}
+		copy(fp[:], b)
+		return fp, nil
+	}
+	return fp, fmt.Errorf("%w: %s", ErrInvalidFingerprint, s)
+}
+
+// ParseFingerprintFile parses the specified file which has a fingerprint per line.
+// Empty lines and lines starting with # are ignored.
+func ParseFingerprintFile(file string) ([]Fingerprint, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fps := []Fingerprint{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fp, err := ParseFingerprint(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, lineNo, err)
+		}
+		fps = append(fps, fp)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fps, nil
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of the DER encoded certificate.
+func CertificateFingerprint(cert *x509.Certificate) Fingerprint {
+	return sha256.Sum256(cert.Raw)
+}
+
+// PublicKeyFingerprint returns the SHA-256 fingerprint of the DER encoded SubjectPublicKeyInfo of the certificate.
+// The fingerprint does not change when the certificate is re-issued with the same key.
+func PublicKeyFingerprint(cert *x509.Certificate) Fingerprint {
+	return sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
+
+// String returns the fingerprint in lower-case hex.
+func (fp Fingerprint) String() string {
+	return hex.EncodeToString(fp[:])
+}
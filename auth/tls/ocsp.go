@@ -0,0 +1,218 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspDefaultTimeout     = 5 * time.Second
+	ocspDefaultClockSkew   = 5 * time.Minute
+	ocspMaxResponseSize    = 1024 * 1024
+	ocspRequestContentType = "application/ocsp-request"
+)
+
+// ErrOCSP is returned when the OCSP status of a certificate cannot be determined.
+var ErrOCSP = errors.New("OCSP check failed")
+
+// HTTPClient is the interface for sending HTTP requests to OCSP responders. *http.Client implements it.
+type HTTPClient interface {
+	// Do sends an HTTP request and returns an HTTP response.
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type ocspResponse struct {
+	status     int
+	revokedAt  time.Time
+	nextUpdate time.Time
+}
+
+// ocspChecker represents a revocation checker using OCSP.
+type ocspChecker struct {
+	sync.Mutex
+	responder string
+	client    HTTPClient
+	softFail  bool
+	clockSkew time.Duration
+	cache     map[string]*ocspResponse
+}
+
+// OCSPCheckerOption is a function to set the OCSP checker options.
+type OCSPCheckerOption = func(*ocspChecker)
+
+// WithOCSPResponder sets the OCSP responder URL which overrides the OCSP server in the authority information access extension.
+func WithOCSPResponder(url string) OCSPCheckerOption {
+	return func(checker *ocspChecker) {
+		checker.responder = url
+	}
+}
+
+// WithOCSPHTTPClient sets the HTTP client used to send OCSP requests.
+func WithOCSPHTTPClient(client HTTPClient) OCSPCheckerOption {
+	return func(checker *ocspChecker) {
+		checker.client = client
+	}
+}
+
+// WithOCSPSoftFail sets whether a certificate is accepted when its OCSP status cannot be determined.
+// In the default hard-fail mode, a certificate whose responder is unreachable or whose status is unknown is rejected.
+// A revoked certificate is always rejected.
+func WithOCSPSoftFail(enabled bool) OCSPCheckerOption {
+	return func(checker *ocspChecker) {
+		checker.softFail = enabled
+	}
+}
+
+// WithOCSPClockSkew sets the clock skew allowed when checking the this update and next update times of OCSP responses.
+// The default is 5 minutes.
+func WithOCSPClockSkew(skew time.Duration) OCSPCheckerOption {
+	return func(checker *ocspChecker) {
+		checker.clockSkew = skew
+	}
+}
+
+// NewOCSPChecker returns a new revocation checker which checks the leaf certificate by OCSP.
+// A good or unknown response whose this update time is in the future or whose next update time is in the past
+// is stale and is treated as an OCSP failure. The fresh responses are cached until their next update time.
+func NewOCSPChecker(opts ...OCSPCheckerOption) RevocationChecker {
+	checker := &ocspChecker{
+		Mutex:     sync.Mutex{},
+		responder: "",
+		client:    &http.Client{Timeout: ocspDefaultTimeout}, // nolint: exhaustruct
+		softFail:  false,
+		clockSkew: ocspDefaultClockSkew,
+		cache:     map[string]*ocspResponse{},
+	}
+	for _, opt := range opts {
+		opt(checker)
+	}
+	return checker
+}
+
+// CheckRevocation checks the OCSP status of the leaf certificate in the verified chain.
+func (checker *ocspChecker) CheckRevocation(chain []*x509.Certificate) error {
+	if len(chain) < 2 {
+		return nil
+	}
+	cert, issuer := chain[0], chain[1]
+	res, err := checker.lookup(cert, issuer)
+	if err == nil && res.status == ocsp.Unknown {
+		err = fmt.Errorf("%w: status is unknown", ErrOCSP)
+	}
+	if err != nil {
+		if checker.softFail {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", cert.Subject, err)
+	}
+	if res.status == ocsp.Revoked {
+		return fmt.Errorf("%w: serial %s of %s at %s", ErrRevoked, cert.SerialNumber, cert.Subject, res.revokedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// lookup returns the cached response or queries the responder.
+func (checker *ocspChecker) lookup(cert *x509.Certificate, issuer *x509.Certificate) (*ocspResponse, error) {
+	spki := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	key := string(spki[:]) + cert.SerialNumber.String()
+
+	checker.Lock()
+	res, ok := checker.cache[key]
+	checker.Unlock()
+	if ok && time.Now().Before(res.nextUpdate) {
+		return res, nil
+	}
+
+	res, err := checker.query(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	checker.Lock()
+	for k, r := range checker.cache {
+		if !time.Now().Before(r.nextUpdate) {
+			delete(checker.cache, k)
+		}
+	}
+	if time.Now().Before(res.nextUpdate) {
+		checker.cache[key] = res
+	}
+	checker.Unlock()
+
+	return res, nil
+}
+
+// query sends an OCSP request to the responder and verifies the response.
+func (checker *ocspChecker) query(cert *x509.Certificate, issuer *x509.Certificate) (*ocspResponse, error) {
+	url := checker.responder
+	if len(url) == 0 {
+		if len(cert.OCSPServer) == 0 {
+			return nil, fmt.Errorf("%w: no OCSP responder", ErrOCSP)
+		}
+		url = cert.OCSPServer[0]
+	}
+	reqBody, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ocspRequestContentType)
+	httpRes, err := checker.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOCSP, err)
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrOCSP, url, httpRes.Status)
+	}
+	resBody, err := io.ReadAll(io.LimitReader(httpRes.Body, ocspMaxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOCSP, err)
+	}
+	// ParseResponseForCert verifies that the response is signed by the issuer or its delegated responder.
+	ocspRes, err := ocsp.ParseResponseForCert(resBody, cert, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOCSP, err)
+	}
+	// A revoked response is kept authoritative even if it is stale because a revocation is permanent.
+	if ocspRes.Status != ocsp.Revoked {
+		now := time.Now()
+		if ocspRes.ThisUpdate.After(now.Add(checker.clockSkew)) {
+			return nil, fmt.Errorf("%w: response is not valid until %s", ErrOCSP, ocspRes.ThisUpdate.Format(time.RFC3339))
+		}
+		if !ocspRes.NextUpdate.IsZero() && ocspRes.NextUpdate.Before(now.Add(-checker.clockSkew)) {
+			return nil, fmt.Errorf("%w: response expired at %s", ErrOCSP, ocspRes.NextUpdate.Format(time.RFC3339))
+		}
+	}
+	return &ocspResponse{
+		status:     ocspRes.Status,
+		revokedAt:  ocspRes.RevokedAt,
+		nextUpdate: ocspRes.NextUpdate,
+	}, nil
+}
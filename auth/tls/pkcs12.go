@@ -0,0 +1,47 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// decodePKCS12 decodes a PKCS#12 bundle, and returns the PEM encoded leaf certificate followed by the chain, and the PEM encoded private key.
+func decodePKCS12(data []byte, provider PassphraseProvider) ([]byte, []byte, error) {
+	password := []byte{}
+	if provider != nil {
+		var err error
+		password, err = provider()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	key, leaf, chain, err := pkcs12.DecodeChain(data, string(password))
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certs := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}) // nolint: exhaustruct
+	for _, cert := range chain {
+		certs = append(certs, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...) // nolint: exhaustruct
+	}
+	return certs, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil // nolint: exhaustruct
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+)
+
+// TLSPolicy represents a preset of the protocol versions, cipher suites and curve preferences.
+type TLSPolicy struct {
+	// Name is the name of the policy.
+	Name string
+	// MinVersion is the minimum TLS version.
+	MinVersion uint16
+	// MaxVersion is the maximum TLS version. Zero means the maximum version supported by crypto/tls.
+	MaxVersion uint16
+	// CipherSuites is the list of TLS 1.0–1.2 cipher suites. Empty means the default cipher suites of crypto/tls.
+	CipherSuites []uint16
+	// CurvePreferences is the list of key exchange mechanisms. Empty means the default curves of crypto/tls.
+	CurvePreferences []tls.CurveID
+}
+
+// ModernTLSPolicy returns the "modern" policy which allows only TLS 1.3.
+func ModernTLSPolicy() TLSPolicy {
+	return TLSPolicy{
+		Name:             "modern",
+		MinVersion:       tls.VersionTLS13,
+		MaxVersion:       0,
+		CipherSuites:     []uint16{},
+		CurvePreferences: []tls.CurveID{tls.X25519MLKEM768, tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+}
+
+// IntermediateTLSPolicy returns the "intermediate" policy which allows TLS 1.2 with forward secret AEAD cipher suites and TLS 1.3.
+func IntermediateTLSPolicy() TLSPolicy {
+	return TLSPolicy{
+		Name:       "intermediate",
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: 0,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519MLKEM768, tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+}
+
+// LookupTLSPolicy returns the policy of the specified name such as "modern" and "intermediate".
+func LookupTLSPolicy(name string) (TLSPolicy, error) {
+	for _, policy := range []TLSPolicy{ModernTLSPolicy(), IntermediateTLSPolicy()} {
+		if policy.Name == name {
+			return policy, nil
+		}
+	}
+	return TLSPolicy{}, fmt.Errorf("unknown TLS policy: %s", name) // nolint: exhaustruct
+}
+
+// checkCipherSuites returns an error if any of the cipher suites is unknown or insecure.
+func checkCipherSuites(suites []uint16) error {
+	for _, id := range suites {
+		if slices.ContainsFunc(tls.InsecureCipherSuites(), func(s *tls.CipherSuite) bool { return s.ID == id }) {
+			return fmt.Errorf("insecure cipher suite: %s", tls.CipherSuiteName(id))
+		}
+		if !slices.ContainsFunc(tls.CipherSuites(), func(s *tls.CipherSuite) bool { return s.ID == id }) {
+			return fmt.Errorf("unknown cipher suite: %s", tls.CipherSuiteName(id))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+// ErrRevoked is returned when a certificate is revoked.
+var ErrRevoked = errors.New("certificate revoked")
+
+// RevocationChecker is the interface for checking the revocation status of certificates.
+type RevocationChecker interface {
+	// CheckRevocation checks the revocation status of the certificates in the verified chain.
+	// The chain starts with the leaf certificate, and each certificate is followed by its issuer.
+	// It returns an error wrapping ErrRevoked if any certificate in the chain is revoked.
+	CheckRevocation(chain []*x509.Certificate) error
+}
+
+// checkVerifiedChains checks the revocation status of all verified chains.
+func checkVerifiedChains(checker RevocationChecker, chains [][]*x509.Certificate) error {
+	for _, chain := range chains {
+		if err := checker.CheckRevocation(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revocationCheckers represents a revocation checker which runs all checkers in order.
+type revocationCheckers []RevocationChecker
+
+// CheckRevocation checks the revocation status by all checkers and returns the first error.
+func (checkers revocationCheckers) CheckRevocation(chain []*x509.Certificate) error {
+	for _, checker := range checkers {
+		if err := checker.CheckRevocation(chain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"strings"
+)
+
+// serverKeyPair represents a server key pair which is selected by the SNI server names.
+type serverKeyPair struct {
+	cert        []byte
+	key         []byte
+	certFile    string
+	keyFile     string
+	serverNames []string
+}
+
+// normalizeServerName returns the server name in lower case without the trailing dot.
+func normalizeServerName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// newServerCertificateSelector returns a function for tls.Config.GetCertificate which selects the certificate by the SNI server name.
+// An exact name is preferred to a wildcard name such as "*.example.com", which matches a single label.
+// If no name matches, the first certificate is returned as the default.
+func newServerCertificateSelector(certs []tls.Certificate, names map[string]int) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		name := normalizeServerName(hello.ServerName)
+		if n, ok := names[name]; ok {
+			return &certs[n], nil
+		}
+		if i := strings.IndexByte(name, '.'); 0 < i {
+			if n, ok := names["*"+name[i:]]; ok {
+				return &certs[n], nil
+			}
+		}
+		return &certs[0], nil
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	spiffeScheme    = "spiffe"
+	spiffeSchemePfx = spiffeScheme + "://"
+	spiffeIDMaxLen  = 2048
+)
+
+// ErrInvalidSPIFFEID is returned when a SPIFFE ID is invalid.
+var ErrInvalidSPIFFEID = errors.New("invalid SPIFFE ID")
+
+// SPIFFEID represents a SPIFFE ID such as spiffe://example.org/ns/default/sa/app.
+type SPIFFEID struct {
+	// TrustDomain is the trust domain name of the SPIFFE ID.
+	TrustDomain string
+	// Path is the path of the SPIFFE ID. It is empty or starts with a slash.
+	Path string
+}
+
+// ParseSPIFFEID parses the specified string as a SPIFFE ID according to the SPIFFE ID specification.
+func ParseSPIFFEID(id string) (*SPIFFEID, error) {
+	if spiffeIDMaxLen < len(id) {
+		return nil, fmt.Errorf("%w: too long", ErrInvalidSPIFFEID)
+	}
+	rest, ok := strings.CutPrefix(id, spiffeSchemePfx)
+	if !ok {
+		return nil, fmt.Errorf("%w: scheme is not %s: %s", ErrInvalidSPIFFEID, spiffeScheme, id)
+	}
+	td, path, _ := strings.Cut(rest, "/")
+	if len(td) == 0 {
+		return nil, fmt.Errorf("%w: trust domain is empty: %s", ErrInvalidSPIFFEID, id)
+	}
+	for _, c := range td {
+		if !isSPIFFETrustDomainChar(c) {
+			return nil, fmt.Errorf("%w: trust domain has an invalid character %q: %s", ErrInvalidSPIFFEID, c, id)
+		}
+	}
+	sid := &SPIFFEID{
+		TrustDomain: td,
+		Path:        "",
+	}
+	if len(path) == 0 && !strings.HasSuffix(rest, "/") {
+		return sid, nil
+	}
+	for segment := range strings.SplitSeq(path, "/") {
+		switch segment {
+		case "":
+			return nil, fmt.Errorf("%w: path has an empty segment: %s", ErrInvalidSPIFFEID, id)
+		case ".", "..":
+			return nil, fmt.Errorf("%w: path has a relative segment: %s", ErrInvalidSPIFFEID, id)
+		}
+		for _, c := range segment {
+			if !isSPIFFEPathChar(c) {
+				return nil, fmt.Errorf("%w: path has an invalid character %q: %s", ErrInvalidSPIFFEID, c, id)
+			}
+		}
+	}
+	sid.Path = "/" + path
+	return sid, nil
+}
+
+// SPIFFEIDFromCertificate returns the SPIFFE ID of the specified X.509-SVID certificate.
+// The certificate must have exactly one URI subject alternative name, which must be a valid SPIFFE ID.
+func SPIFFEIDFromCertificate(cert *x509.Certificate) (*SPIFFEID, error) {
+	if len(cert.URIs) != 1 {
+		return nil, fmt.Errorf("%w: certificate has %d URI SANs", ErrInvalidSPIFFEID, len(cert.URIs))
+	}
+	return ParseSPIFFEID(cert.URIs[0].String())
+}
+
+// String returns the string representation of the SPIFFE ID.
+func (id *SPIFFEID) String() string {
+	return spiffeSchemePfx + id.TrustDomain + id.Path
+}
+
+func isSPIFFETrustDomainChar(c rune) bool {
+	switch {
+	case 'a' <= c && c <= 'z':
+		return true
+	case '0' <= c && c <= '9':
+		return true
+	case c == '.' || c == '-' || c == '_':
+		return true
+	}
+	return false
+}
+
+func isSPIFFEPathChar(c rune) bool {
+	switch {
+	case 'a' <= c && c <= 'z':
+		return true
+	case 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return true
+	case c == '.' || c == '-' || c == '_':
+		return true
+	}
+	return false
+}
+
+// hasSPIFFEID returns true if the specified certificate has a URI subject alternative name with the spiffe scheme.
+func hasSPIFFEID(cert *x509.Certificate) bool {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == spiffeScheme {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySPIFFEConnection returns a function which checks that a peer X.509-SVID was verified against the trust bundle of its trust domain.
+// The function is called after crypto/tls has verified the peer certificate chain, so the verified chains are kept in the connection state.
+// Because the client CAs include the trust bundles, a peer certificate without a SPIFFE ID is verified again against the roots,
+// which do not include the trust bundles, so that a trust bundle CA cannot issue a non-SPIFFE client certificate.
+func verifySPIFFEConnection(bundles map[string][]*x509.Certificate, roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		leaf := state.PeerCertificates[0]
+		if !hasSPIFFEID(leaf) {
+			return verifyNonSPIFFECertificate(state.PeerCertificates, roots)
+		}
+		if leaf.IsCA {
+			return fmt.Errorf("%w: leaf certificate is a CA certificate", ErrInvalidSPIFFEID)
+		}
+		id, err := SPIFFEIDFromCertificate(leaf)
+		if err != nil {
+			return err
+		}
+		bundle, ok := bundles[id.TrustDomain]
+		if !ok {
+			return fmt.Errorf("%w: no trust bundle for trust domain: %s", ErrInvalidSPIFFEID, id.TrustDomain)
+		}
+		for _, chain := range state.VerifiedChains {
+			anchor := chain[len(chain)-1]
+			for _, cert := range bundle {
+				if cert.Equal(anchor) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%w: %s is not issued by the trust bundle of %s", ErrInvalidSPIFFEID, id.String(), id.TrustDomain)
+	}
+}
+
+// verifyNonSPIFFECertificate verifies the peer certificate chain without a SPIFFE ID against the roots.
+func verifyNonSPIFFECertificate(certs []*x509.Certificate, roots *x509.CertPool) error {
+	if roots == nil {
+		return errors.New("certificate without a SPIFFE ID is not issued by the root certificates")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{ // nolint: exhaustruct
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("certificate without a SPIFFE ID is not issued by the root certificates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"os"
+	"time"
+)
+
+// fileStamp represents the modification time and size of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileWatcher represents a polling watcher which detects changes of files by their modification times and sizes.
+// Symbolic links are followed, so an atomic symlink swap such as a Kubernetes secret update is detected.
+type fileWatcher struct {
+	files  []string
+	stamps map[string]fileStamp
+	// observed holds the stamps read by the last Changed call until they are committed by Commit.
+	observed map[string]fileStamp
+}
+
+func newFileWatcher(files ...string) *fileWatcher {
+	w := &fileWatcher{
+		files:    files,
+		stamps:   map[string]fileStamp{},
+		observed: nil,
+	}
+	if _, err := w.Changed(); err == nil {
+		w.Commit()
+	}
+	return w
+}
+
+// Changed returns true if any of the files has changed since the stamps were last committed by Commit.
+// The stamps are not committed, so the change is reported again until Commit is called, for example when
+// reloading the files fails. An error is returned if any of the files cannot be accessed.
+func (w *fileWatcher) Changed() (bool, error) {
+	stamps := make(map[string]fileStamp, len(w.files))
+	for _, file := range w.files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		stamps[file] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	changed := false
+	for file, stamp := range stamps {
+		last, ok := w.stamps[file]
+		if !ok || !last.modTime.Equal(stamp.modTime) || last.size != stamp.size {
+			changed = true
+		}
+	}
+	w.observed = stamps
+	return changed, nil
+}
+
+// Commit records the stamps read by the last Changed call as the current state of the files.
+func (w *fileWatcher) Commit() {
+	if w.observed == nil {
+		return
+	}
+	w.stamps = w.observed
+	w.observed = nil
+}
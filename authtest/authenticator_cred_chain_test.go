@@ -0,0 +1,193 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+)
+
+type testErrorAuthenticator struct{}
+
+func (a *testErrorAuthenticator) VerifyCredential(conn auth.Conn, q auth.Query) (bool, error) {
+	return false, errors.New("unavailable")
+}
+
+func newTestPasswordStore(group string, username string, password string) auth.CredentialStore {
+	return &testCredentialStore{
+		creds: map[string]auth.Credential{
+			username: auth.NewCredential(
+				auth.WithCredentialGroup(group),
+				auth.WithCredentialUsername(username),
+				auth.WithCredentialPassword(password),
+			),
+		},
+	}
+}
+
+func TestCredentialAuthenticatorChain(t *testing.T) {
+	localStore := newTestPasswordStore("local", "alice", "secret")
+	remoteStore := newTestPasswordStore("remote", "bob", "secret")
+	type newEntry = func(string, auth.CredentialAuthenticator) auth.CredentialChainEntry
+	local := func(entry newEntry) auth.CredentialChainEntry {
+		return entry("local", auth.NewCredentialAuthenticator()).WithStore(localStore)
+	}
+	remote := func(entry newEntry) auth.CredentialChainEntry {
+		return entry("remote", auth.NewCredentialAuthenticator()).WithStore(remoteStore)
+	}
+	down := &testErrorAuthenticator{}
+
+	tests := []struct {
+		name     string
+		entries  []auth.CredentialChainEntry
+		username string
+		expected bool
+		count    int
+	}{
+		{"empty", nil, "alice", false, 0},
+		{"sufficient first", []auth.CredentialChainEntry{local(auth.SufficientCredential), remote(auth.RequiredCredential)}, "alice", true, 1},
+		{"sufficient fallback", []auth.CredentialChainEntry{local(auth.SufficientCredential), remote(auth.RequiredCredential)}, "bob", true, 2},
+		{"sufficient unavailable", []auth.CredentialChainEntry{auth.SufficientCredential("down", down), remote(auth.SufficientCredential)}, "bob", true, 2},
+		{"sufficient all failed", []auth.CredentialChainEntry{local(auth.SufficientCredential), remote(auth.SufficientCredential)}, "carol", false, 2},
+		{"required both", []auth.CredentialChainEntry{local(auth.RequiredCredential), remote(auth.RequiredCredential)}, "alice", false, 2},
+		{"required then sufficient", []auth.CredentialChainEntry{remote(auth.RequiredCredential), local(auth.SufficientCredential)}, "alice", false, 2},
+		{"requisite", []auth.CredentialChainEntry{remote(auth.RequisiteCredential), local(auth.SufficientCredential)}, "alice", false, 1},
+		{"optional only", []auth.CredentialChainEntry{auth.OptionalCredential("down", down), local(auth.OptionalCredential)}, "alice", true, 2},
+		{"optional with required", []auth.CredentialChainEntry{local(auth.RequiredCredential), remote(auth.OptionalCredential)}, "alice", true, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := auth.NewQuery(
+				auth.WithQueryUsername(test.username),
+				auth.WithQueryPassword("secret"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain := auth.NewCredentialAuthenticatorChain(test.entries...)
+			trace, err := chain.VerifyCredentialTrace(nil, q)
+			if trace.Result != test.expected {
+				t.Errorf("%s: %v", trace, err)
+			}
+			if len(trace.Decisions) != test.count {
+				t.Errorf("%d decisions: %s", len(trace.Decisions), trace)
+			}
+			if trace.Result && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// testStoreAuthenticator is a credential authenticator which does not resolve the principal.
+type testStoreAuthenticator struct {
+	store auth.CredentialStore
+}
+
+func (a *testStoreAuthenticator) SetCredentialStore(store auth.CredentialStore) {
+	a.store = store
+}
+
+func (a *testStoreAuthenticator) VerifyCredential(conn auth.Conn, q auth.Query) (bool, error) {
+	cred, ok, err := a.store.LookupCredential(q)
+	if !ok {
+		return false, err
+	}
+	return cred.Password() == q.Password(), nil
+}
+
+func TestManagerCredentialAuthenticators(t *testing.T) {
+	mgr := auth.NewManager()
+	mgr.SetCredentialStore(newTestPasswordStore("admin", "bob", "admin-secret"))
+	mgr.SetCredentialAuthenticators(
+		auth.SufficientCredential("local", auth.NewCredentialAuthenticator()).WithStore(newTestPasswordStore("local", "alice", "secret")),
+		auth.SufficientCredential("ldap", &testStoreAuthenticator{store: nil}).WithStore(newTestPasswordStore("ldap", "carol", "secret")),
+		auth.RequiredCredential("remote", auth.NewCredentialAuthenticator()).WithStore(newTestPasswordStore("remote", "bob", "secret")),
+	)
+
+	principals := []struct {
+		username string
+		group    string
+	}{
+		{"alice", "local"},
+		{"carol", "ldap"},
+		{"bob", "remote"},
+	}
+	for _, expected := range principals {
+		q, err := auth.NewQuery(
+			auth.WithQueryUsername(expected.username),
+			auth.WithQueryPassword("secret"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := mgr.VerifyCredential(nil, q)
+		if !ok || err != nil {
+			t.Fatalf("%s: %v %v", expected.username, ok, err)
+		}
+		p, ok, err := mgr.VerifyCredentialPrincipal(nil, q)
+		if !ok || err != nil {
+			t.Fatalf("%s: %v %v", expected.username, ok, err)
+		}
+		if p.Username() != expected.username || p.Group() != expected.group {
+			t.Errorf("unexpected principal: %v", p)
+		}
+	}
+
+	q, err := auth.NewQuery(
+		auth.WithQueryUsername("bob"),
+		auth.WithQueryPassword("admin-secret"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := mgr.VerifyCredentialPrincipal(nil, q); ok {
+		t.Errorf("credential of the manager store should not be used by the chain")
+	}
+	trace, _ := mgr.VerifyCredentialTrace(nil, q)
+	if trace.Result || len(trace.Decisions) != 3 || trace.Principal != nil {
+		t.Errorf("unexpected trace: %s", trace)
+	}
+}
+
+func TestManagerCredentialAuthenticatorsStore(t *testing.T) {
+	q, err := auth.NewQuery(
+		auth.WithQueryUsername("alice"),
+		auth.WithQueryPassword("invalid"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := auth.NewManager()
+	mgr.SetCredentialAuthenticators(auth.RequiredCredential("db", auth.NewCredentialAuthenticator()))
+	if ok, err := mgr.VerifyCredential(nil, q); ok || !errors.Is(err, auth.ErrNoCredentialStore) {
+		t.Errorf("chain without a store should refuse to verify: %v %v", ok, err)
+	}
+
+	mgr = auth.NewManager()
+	mgr.SetCredentialStore(newTestPasswordStore("admin", "alice", "secret"))
+	mgr.SetCredentialAuthenticators(auth.RequiredCredential("db", auth.NewCredentialAuthenticator()))
+	if ok, err := mgr.VerifyCredential(nil, q); ok || err != nil {
+		t.Errorf("invalid password should be rejected: %v %v", ok, err)
+	}
+	q.SetPassword("secret")
+	if ok, err := mgr.VerifyCredential(nil, q); !ok || err != nil {
+		t.Errorf("valid password should be accepted: %v %v", ok, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestParseSPIFFEID(t *testing.T) {
+	valids := []string{
+		"spiffe://example.org",
+		"spiffe://example.org/ns/default/sa/app",
+		"spiffe://corp-1.example_org/a.b-c_d",
+	}
+	for _, id := range valids {
+		sid, err := tls.ParseSPIFFEID(id)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if sid.String() != id {
+			t.Errorf("%s != %s", sid.String(), id)
+		}
+	}
+
+	invalids := []string{
+		"https://example.org/app",
+		"spiffe://",
+		"spiffe://Example.org/app",
+		"spiffe://example.org:8080/app",
+		"spiffe://user@example.org/app",
+		"spiffe://example.org/",
+		"spiffe://example.org//app",
+		"spiffe://example.org/../app",
+		"spiffe://example.org/app?q=1",
+		"spiffe://example.org/app#frag",
+	}
+	for _, id := range invalids {
+		if _, err := tls.ParseSPIFFEID(id); err == nil {
+			t.Errorf("%s should be invalid", id)
+		}
+	}
+}
+
+func newTestSVID(t *testing.T, id string, ca *certgen.CA) gotls.Certificate {
+	t.Helper()
+	return newTestCertificate(t, ca,
+		certgen.WithCommonName("svid"),
+		certgen.WithURIs(id),
+		certgen.WithExtKeyUsages(x509.ExtKeyUsageClientAuth),
+	).TLSCertificate()
+}
+
+func TestSPIFFEAuthenticator(t *testing.T) {
+	corpCA := newTestCA(t, nil, certgen.WithCommonName("corp"))
+	otherCA := newTestCA(t, nil, certgen.WithCommonName("other"))
+	rootCA := newTestCA(t, nil, certgen.WithCommonName("root"))
+	plainCert := newTestCertificate(t, rootCA, certgen.WithCommonName("plain"))
+	bundleCert := newTestCertificate(t, corpCA, certgen.WithCommonName("admin"))
+	server := newTestServerCertificate(t, nil)
+
+	conf := tls.NewCertConfig()
+	conf.SetServerCert(server.CertificatePEM())
+	conf.SetServerKey(server.KeyPEM())
+	conf.SetRootCerts(rootCA.CertificatePEM())
+	conf.SetTrustBundle("corp.example", corpCA.CertificatePEM())
+	conf.SetTrustBundle("other.example", otherCA.CertificatePEM())
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sa, err := auth.NewSPIFFEAuthenticator(
+		auth.WithSPIFFETrustDomain("corp.example"),
+		auth.WithSPIFFEPathRegexp("^/ns/payments/"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		svid      gotls.Certificate
+		handshake bool
+		ok        bool
+	}{
+		{newTestSVID(t, "spiffe://corp.example/ns/payments/sa/billing", corpCA), true, true},
+		{newTestSVID(t, "spiffe://corp.example/ns/shipping/sa/billing", corpCA), true, false},
+		{newTestSVID(t, "spiffe://other.example/ns/payments/sa/billing", otherCA), true, false},
+		{newTestSVID(t, "spiffe://corp.example/ns/payments/sa/billing", otherCA), false, false},
+		{newTestSVID(t, "spiffe://unknown.example/ns/payments/sa/billing", corpCA), false, false},
+		{newTestSVID(t, "spiffe://corp.example/ns/payments/sa/billing", nil), false, false},
+		{newTestSVID(t, "spiffe://corp.example/ns/payments/sa/billing", rootCA), false, false},
+		{plainCert.TLSCertificate(), true, false},
+		{bundleCert.TLSCertificate(), false, false},
+	}
+
+	for n, test := range tests {
+		state, err := testHandshake(t, serverConfig, &gotls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []gotls.Certificate{test.svid},
+		})
+		if (err == nil) != test.handshake {
+			t.Errorf("[%d] handshake: %v", n, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(state.VerifiedChains) == 0 {
+			t.Errorf("[%d] verified chains should be kept", n)
+		}
+		p, ok, err := sa.(auth.CertificatePrincipalAuthenticator).VerifyCertificatePrincipal(&testConn{state: state})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("[%d] %v != %v", n, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if p.Group() != "corp.example" {
+			t.Errorf("[%d] %s != %s", n, p.Group(), "corp.example")
+		}
+		for _, group := range []string{"corp.example", ""} {
+			q, err := auth.NewQuery(auth.WithQueryUsername(p.Username()), auth.WithQueryGroup(group))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, ok, _ := sa.(auth.CertificatePrincipalAuthenticator).VerifyCertificateQuery(&testConn{state: state}, q)
+			if ok != (group == "corp.example") {
+				t.Errorf("[%d] query group %q: %v", n, group, ok)
+			}
+		}
+	}
+
+	conf.SetClientAuthType(gotls.RequestClientCert)
+	if _, err := conf.TLSConfig(); err == nil {
+		t.Error("trust bundles without client certificate verification should be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+type testRevocationChecker struct {
+	err error
+}
+
+func (checker *testRevocationChecker) CheckRevocation(chain []*x509.Certificate) error {
+	return checker.err
+}
+
+func TestCertificateAuthenticatorCombinators(t *testing.T) {
+	rootCA := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	root := rootCA.Certificate()
+	newLeaf := func(cn string, dnsName string) *x509.Certificate {
+		return newTestCertificate(t, rootCA, certgen.WithCommonName(cn), certgen.WithDNSNames(dnsName)).Certificate()
+	}
+	ops1 := newLeaf("ops-1", "ops-1.example.com")
+	ops2 := newLeaf("ops-2", "ops-2.example.com")
+	dev1 := newLeaf("dev-1", "dev-1.example.com")
+	legacy := newLeaf("legacy", "legacy.example.com")
+
+	newAuthenticator := func(opts ...auth.CertificateAuthenticatorOption) auth.CertificateAuthenticator {
+		ca, err := auth.NewCertificateAuthenticator(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ca
+	}
+	issuedByCorp := newAuthenticator(auth.WithIssuerCommonNameRegexp("^Corp CA$"))
+	opsSAN := newAuthenticator(auth.WithDNSNameRegexp(`^ops-.*\.example\.com$`))
+	pinned := newAuthenticator(auth.WithCertificateFingerprint(tls.CertificateFingerprint(legacy).String()))
+	denylist := newAuthenticator(auth.WithCertificateFingerprint(tls.CertificateFingerprint(ops2).String()))
+
+	// issued by Corp CA AND (SAN matches ops-* OR fingerprint pinned) AND NOT in denylist
+	ca := auth.AllOf(issuedByCorp, auth.AnyOf(opsSAN, pinned), auth.Not(denylist))
+
+	tests := []struct {
+		cert *x509.Certificate
+		ok   bool
+	}{
+		{ops1, true},
+		{ops2, false},
+		{dev1, false},
+		{legacy, true},
+	}
+	for _, test := range tests {
+		p, ok, err := ca.VerifyCertificatePrincipal(newTestVerifiedConn(test.cert, root))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("%s: %v != %v", test.cert.Subject.CommonName, ok, test.ok)
+			continue
+		}
+		if ok && p.Username() != test.cert.Subject.CommonName {
+			t.Errorf("%s != %s", p.Username(), test.cert.Subject.CommonName)
+		}
+	}
+
+	if ok, _ := ca.VerifyCertificate(newTestConn(ops1, root)); ok {
+		t.Error("unverified chain should be rejected")
+	}
+	if ok, _ := auth.Not(denylist).VerifyCertificate(newTestConn()); ok {
+		t.Error("connection without certificates should be rejected")
+	}
+	if ok, _ := auth.AllOf().VerifyCertificate(newTestConn(ops1)); ok {
+		t.Error("empty AllOf should reject")
+	}
+
+	q, err := auth.NewQuery(auth.WithQueryUsername("ops-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := ca.VerifyCertificateQuery(newTestVerifiedConn(ops1, root), q); !ok {
+		t.Error("query should be matched")
+	}
+	q, err = auth.NewQuery(auth.WithQueryUsername("dev-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := ca.VerifyCertificateQuery(newTestVerifiedConn(ops1, root), q); ok {
+		t.Error("query should not be matched")
+	}
+
+	// The first matching rule decides.
+
+	fm := auth.FirstMatch(
+		auth.DenyCertificate(denylist),
+		auth.AllowCertificate(opsSAN),
+		auth.AllowCertificate(pinned))
+	tests = []struct {
+		cert *x509.Certificate
+		ok   bool
+	}{
+		{ops1, true},
+		{ops2, false},
+		{dev1, false},
+		{legacy, true},
+	}
+	for _, test := range tests {
+		if ok, err := fm.VerifyCertificate(newTestConn(test.cert)); ok != test.ok || err != nil {
+			t.Errorf("%s: %v != %v (%v)", test.cert.Subject.CommonName, ok, test.ok, err)
+		}
+	}
+
+	// A certificate policy violation does not stop the evaluation, but a revocation check error does.
+
+	shortLived := newAuthenticator(auth.WithDNSNameRegexp(".*"), auth.WithMaxValidityPeriod(time.Minute))
+	anyOf := auth.AnyOf(shortLived, opsSAN)
+	if ok, err := anyOf.VerifyCertificate(newTestVerifiedConn(ops1, root)); !ok || err != nil {
+		t.Errorf("policy violation should fall through to the next authenticator: %v %v", ok, err)
+	}
+	if ok, err := auth.Not(shortLived).VerifyCertificate(newTestVerifiedConn(ops1, root)); !ok || err != nil {
+		t.Errorf("policy violation should be a rejection: %v %v", ok, err)
+	}
+	unavailable := newAuthenticator(
+		auth.WithDNSNameRegexp(".*"),
+		auth.WithRevocationChecker(&testRevocationChecker{err: tls.ErrOCSP}))
+	if ok, err := auth.AnyOf(unavailable, opsSAN).VerifyCertificate(newTestVerifiedConn(ops1, root)); ok || !errors.Is(err, tls.ErrOCSP) {
+		t.Errorf("revocation check error should stop the evaluation: %v %v", ok, err)
+	}
+}
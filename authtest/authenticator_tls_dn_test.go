@@ -0,0 +1,117 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestParseDistinguishedName(t *testing.T) {
+	tests := []struct {
+		dn       string
+		expected string
+	}{
+		{"CN=app,OU=payments,O=Corp", "CN=app,OU=payments,O=Corp"},
+		{"cn = app , o = Corp", "CN=app,O=Corp"},
+		{`CN=Smith\, John,O=Corp`, `CN=Smith\, John,O=Corp`},
+		{`CN=\41pp,O=Corp`, "CN=App,O=Corp"},
+		{"CN=app+UID=1000,O=Corp", "CN=app+0.9.2342.19200300.100.1.1=1000,O=Corp"},
+		{"serialNumber=42,C=JP", "SERIALNUMBER=42,C=JP"},
+	}
+	for _, test := range tests {
+		rdns, err := tls.ParseDistinguishedName(test.dn)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if rdns.String() != test.expected {
+			t.Errorf("%s != %s", rdns.String(), test.expected)
+		}
+	}
+
+	for _, dn := range []string{"CN", "XX=app", "CN=app,", `CN=app\`, "CN=#0403616070"} {
+		if _, err := tls.ParseDistinguishedName(dn); err == nil {
+			t.Errorf("%s should be invalid", dn)
+		}
+	}
+}
+
+func TestCertificateAuthenticatorDN(t *testing.T) {
+	rootCA := newTestCA(t, nil, certgen.WithSubject(pkix.Name{CommonName: "Corp Root CA", Organization: []string{"Corp"}}))
+	issuingCA := newTestCA(t, rootCA, certgen.WithSubject(pkix.Name{CommonName: "Corp Issuing CA 2", Organization: []string{"Corp"}}))
+	root, issuing := rootCA.Certificate(), issuingCA.Certificate()
+	leaf := newTestCertificate(t, issuingCA, certgen.WithSubject(pkix.Name{
+		CommonName:         "billing",
+		OrganizationalUnit: []string{"payments"},
+		Organization:       []string{"Corp"},
+		Country:            []string{"JP"},
+		SerialNumber:       "1234",
+	})).Certificate()
+	conn := newTestVerifiedConn(leaf, issuing, root)
+
+	tests := []struct {
+		opts     []auth.CertificateAuthenticatorOption
+		expected bool
+	}{
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectDN("SERIALNUMBER=1234,CN=billing,OU=payments,O=Corp,C=JP")}, true},
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectDN("CN=billing,OU=payments,O=Corp")}, false},
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectAttributes("OU=payments,C=JP")}, true},
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectAttributes("OU=shipping,C=JP")}, false},
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectAttributeRegexp("serialNumber", "^12")}, true},
+		{[]auth.CertificateAuthenticatorOption{auth.WithSubjectDNRegexp("OU=pay")}, true},
+		{[]auth.CertificateAuthenticatorOption{
+			auth.WithSubjectAttributes("OU=payments"),
+			auth.WithIssuerDN("CN=Corp Issuing CA 2,O=Corp"),
+		}, true},
+		{[]auth.CertificateAuthenticatorOption{
+			auth.WithSubjectAttributes("OU=payments"),
+			auth.WithIssuerAttributes("CN=Corp Issuing CA 1"),
+		}, false},
+		{[]auth.CertificateAuthenticatorOption{
+			auth.WithSubjectAttributes("OU=payments"),
+			auth.WithIssuerAttributeRegexp("O", "^Corp$"),
+			auth.WithIssuerDNRegexp("Issuing"),
+		}, true},
+	}
+	for n, test := range tests {
+		ca, err := auth.NewCertificateAuthenticator(test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := ca.VerifyCertificate(conn)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.expected {
+			t.Errorf("[%d] %v != %v", n, ok, test.expected)
+		}
+	}
+
+	ca, err := auth.NewCertificateAuthenticator(
+		auth.WithSubjectAttributes("OU=payments"),
+		auth.WithIssuerDN("CN=Corp Issuing CA 2,O=Corp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ca.VerifyCertificate(newTestConn(leaf, issuing, root)); ok {
+		t.Error("issuer rules should require verified chains")
+	}
+}
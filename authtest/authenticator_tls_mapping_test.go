@@ -0,0 +1,117 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertificateMapping(t *testing.T) {
+	svcCert := newTestCertificate(t, nil, certgen.WithCommonName("app.svc.example.com")).Certificate()
+	spiffeCert := newTestCertificate(t, nil,
+		certgen.WithCommonName("workload"),
+		certgen.WithURIs("spiffe://corp/ns/payments/sa/billing"),
+	).Certificate()
+	otherCert := newTestCertificate(t, nil, certgen.WithCommonName("other")).Certificate()
+
+	ca, err := auth.NewCertificateAuthenticator(
+		auth.WithCertificateMapping(auth.CertificateSubject, `^CN=(.*)\.svc\.example\.com$`, `\1`, ""),
+		auth.WithCertificateMapping(auth.CertificateURI, `^spiffe://corp/ns/(.*)/sa/(.*)$`, `\2`, `\1`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := auth.NewManager()
+
+	literal, err := auth.NewCertificateAuthenticator(
+		auth.WithCertificateMapping(auth.CertificateCommonName, `^(.*)$`, `$1-\1`, ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetCertificateAuthenticator(literal)
+	p, ok, err := mgr.VerifyCertificatePrincipal(newTestConn(otherCert))
+	if !ok || err != nil {
+		t.Fatalf("%v %v", ok, err)
+	}
+	if p.Username() != "$1-other" {
+		t.Errorf("%s != %s", p.Username(), "$1-other")
+	}
+
+	mgr.SetCertificateAuthenticator(ca)
+
+	tests := []struct {
+		cert     *x509.Certificate
+		ok       bool
+		username string
+		group    string
+	}{
+		{svcCert, true, "app", ""},
+		{spiffeCert, true, "billing", "payments"},
+		{otherCert, false, "", ""},
+	}
+	for _, test := range tests {
+		p, ok, err := mgr.VerifyCertificatePrincipal(newTestConn(test.cert))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("%s: %v != %v", test.cert.Subject, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if p.Username() != test.username || p.Group() != test.group {
+			t.Errorf("%s: (%s, %s) != (%s, %s)", test.cert.Subject, p.Username(), p.Group(), test.username, test.group)
+		}
+	}
+
+	queries := []struct {
+		cert     *x509.Certificate
+		username string
+		group    string
+		ok       bool
+	}{
+		{spiffeCert, "billing", "payments", true},
+		{spiffeCert, "billing", "", false},
+		{spiffeCert, "billing", "shipping", false},
+		{spiffeCert, "admin", "payments", false},
+		{svcCert, "app", "", true},
+		{svcCert, "app", "admin", false},
+	}
+	for _, query := range queries {
+		q, err := auth.NewQuery(
+			auth.WithQueryUsername(query.username),
+			auth.WithQueryGroup(query.group),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok, err := mgr.VerifyCertificateQuery(newTestConn(query.cert), q)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != query.ok {
+			t.Errorf("%s/%s: %v != %v", query.group, query.username, ok, query.ok)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertificateAuthenticatorPinning(t *testing.T) {
+	pinnedCert := newTestCertificate(t, nil, certgen.WithCommonName("break-glass"))
+	pinned, key := pinnedCert.Certificate(), pinnedCert.PrivateKey()
+	other := newTestCertificate(t, nil, certgen.WithCommonName("break-glass")).Certificate()
+
+	// Re-issue the pinned certificate with the same key.
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1000),
+		Subject:      pinned.Subject,
+		NotBefore:    pinned.NotBefore,
+		NotAfter:     pinned.NotAfter.AddDate(1, 0, 0),
+	}, pinned, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reissuedCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFP := tls.CertificateFingerprint(pinned)
+	spkiFP := tls.PublicKeyFingerprint(pinned)
+
+	hexWithColons := strings.ToUpper(certFP.String()[:2])
+	for n := 2; n < len(certFP.String()); n += 2 {
+		hexWithColons += ":" + strings.ToUpper(certFP.String()[n:n+2])
+	}
+	for _, s := range []string{certFP.String(), hexWithColons, base64.StdEncoding.EncodeToString(certFP[:])} {
+		fp, err := tls.ParseFingerprint(s)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if fp != certFP {
+			t.Errorf("%s != %s", fp, certFP)
+		}
+	}
+	if _, err := tls.ParseFingerprint("abcd"); err == nil {
+		t.Error("short fingerprint should be rejected")
+	}
+
+	file := filepath.Join(t.TempDir(), "pins.txt")
+	pins := "# break-glass clients\n\nsha256/" + base64.StdEncoding.EncodeToString(spkiFP[:]) + "\n"
+	if err := os.WriteFile(file, []byte(pins), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opt  auth.CertificateAuthenticatorOption
+		cert *x509.Certificate
+		ok   bool
+	}{
+		{auth.WithCertificateFingerprint(certFP.String()), pinned, true},
+		{auth.WithCertificateFingerprint(certFP.String()), other, false},
+		{auth.WithCertificateFingerprint(certFP.String()), reissuedCert, false},
+		{auth.WithPublicKeyFingerprintFile(file), pinned, true},
+		{auth.WithPublicKeyFingerprintFile(file), reissuedCert, true},
+		{auth.WithPublicKeyFingerprintFile(file), other, false},
+	}
+	for n, test := range tests {
+		ca, err := auth.NewCertificateAuthenticator(test.opt, auth.WithIssuerCommonNameRegexp("^Corp CA$"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := ca.VerifyCertificate(newTestConn(test.cert))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.ok {
+			t.Errorf("[%d] %v != %v", n, ok, test.ok)
+		}
+	}
+}
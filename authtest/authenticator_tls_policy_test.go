@@ -0,0 +1,99 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertificateAuthenticatorPolicy(t *testing.T) {
+	policyOID, err := x509.ParseOID("1.3.6.1.4.1.99999.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := newTestCertificate(t, nil,
+		certgen.WithCommonName("client"),
+		certgen.WithExtKeyUsages(x509.ExtKeyUsageClientAuth),
+		certgen.WithPolicies(policyOID),
+		certgen.WithValidity(time.Now().Add(-time.Hour), time.Now().Add(23*time.Hour)),
+	).Certificate()
+
+	tests := []struct {
+		name string
+		opt  auth.CertificateAuthenticatorOption
+		ok   bool
+	}{
+		{"client auth", auth.WithRequiredExtKeyUsage(x509.ExtKeyUsageClientAuth), true},
+		{"server auth", auth.WithRequiredExtKeyUsage(x509.ExtKeyUsageServerAuth), false},
+		{"ecdsa", auth.WithPublicKeyAlgorithms(x509.ECDSA, x509.Ed25519), true},
+		{"rsa only", auth.WithPublicKeyAlgorithms(x509.RSA), false},
+		{"p256", auth.WithMinimumKeySize(x509.ECDSA, 256), true},
+		{"p384", auth.WithMinimumKeySize(x509.ECDSA, 384), false},
+		{"rsa size", auth.WithMinimumKeySize(x509.RSA, 4096), true},
+		{"sha256", auth.WithSignatureAlgorithms(x509.ECDSAWithSHA256), true},
+		{"sha384", auth.WithSignatureAlgorithms(x509.ECDSAWithSHA384), false},
+		{"one day", auth.WithMaxValidityPeriod(24 * time.Hour), true},
+		{"one hour", auth.WithMaxValidityPeriod(time.Hour), false},
+		{"policy", auth.WithRequiredPolicyOIDs("1.3.6.1.4.1.99999.1"), true},
+		{"other policy", auth.WithRequiredPolicyOIDs("1.3.6.1.4.1.99999.2"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ca, err := auth.NewCertificateAuthenticator(test.opt, auth.WithCommonNameRegexp("^client$"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := ca.VerifyCertificate(newTestConn(cert))
+			if ok != test.ok {
+				t.Errorf("%v != %v (%v)", ok, test.ok, err)
+			}
+			if !test.ok && !errors.Is(err, auth.ErrCertificatePolicy) {
+				t.Errorf("%v is not %v", err, auth.ErrCertificatePolicy)
+			}
+		})
+	}
+
+	if _, err := auth.NewCertificateAuthenticator(auth.WithMinimumKeySize(x509.Ed25519, 256)); err == nil {
+		t.Error("minimum key size for Ed25519 should be rejected")
+	}
+	if _, err := auth.NewCertificateAuthenticator(auth.WithRequiredPolicyOIDs("invalid")); err == nil {
+		t.Error("invalid policy OID should be rejected")
+	}
+
+	// The extended key usages are reported by their names, and the unknown ones by their OIDs.
+
+	cert = newTestCertificate(t, nil,
+		certgen.WithCommonName("client"),
+		certgen.WithExtKeyUsages(x509.ExtKeyUsageClientAuth),
+	).Certificate()
+	cert.UnknownExtKeyUsage = []asn1.ObjectIdentifier{{1, 3, 6, 1, 4, 1, 99999, 2}}
+	ca, err := auth.NewCertificateAuthenticator(auth.WithRequiredExtKeyUsage(x509.ExtKeyUsageServerAuth), auth.WithCommonNameRegexp("^client$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ca.VerifyCertificate(newTestConn(cert))
+	if err == nil || !strings.Contains(err.Error(), "extended key usage serverAuth (has clientAuth, 1.3.6.1.4.1.99999.2)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertificateAuthenticatorSubjectAltName(t *testing.T) {
+	leaf := newTestCertificate(t, nil,
+		certgen.WithCommonName("client"),
+		certgen.WithDNSNames("app.svc.example.com"),
+		certgen.WithEmailAddresses("alice@example.com"),
+		certgen.WithURIs("spiffe://example.com/ns/default/sa/app"),
+		certgen.WithIPAddresses(net.ParseIP("10.0.1.2")),
+	).Certificate()
+
+	tests := []struct {
+		opt      auth.CertificateAuthenticatorOption
+		expected bool
+	}{
+		{auth.WithCommonNameRegexp("^client$"), true},
+		{auth.WithCommonNameRegexp("^server$"), false},
+		{auth.WithDNSNameRegexp(`^.*\.svc\.example\.com$`), true},
+		{auth.WithDNSNameRegexp(`^.*\.svc\.example\.org$`), false},
+		{auth.WithEmailAddressRegexp(`@example\.com$`), true},
+		{auth.WithEmailAddressRegexp(`@example\.org$`), false},
+		{auth.WithURIRegexp(`^spiffe://example\.com/`), true},
+		{auth.WithURIRegexp(`^spiffe://example\.org/`), false},
+		{auth.WithIPAddressCIDR("10.0.0.0/16"), true},
+		{auth.WithIPAddressCIDR("192.168.0.0/16"), false},
+	}
+
+	for _, test := range tests {
+		ca, err := auth.NewCertificateAuthenticator(test.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := ca.VerifyCertificate(newTestConn(leaf))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ok != test.expected {
+			t.Errorf("%v != %v", ok, test.expected)
+		}
+	}
+
+	_, err := auth.NewCertificateAuthenticator(auth.WithIPAddressCIDR("10.0.0.0"))
+	if err == nil {
+		t.Error("invalid CIDR should be rejected")
+	}
+}
+
+func TestCertificateAuthenticatorLeafOnly(t *testing.T) {
+	rootCA := newTestCA(t, nil, certgen.WithCommonName("Root CA"))
+	interCA := newTestCA(t, rootCA, certgen.WithCommonName("admin"))
+	root, inter := rootCA.Certificate(), interCA.Certificate()
+	leaf := newTestCertificate(t, interCA, certgen.WithCommonName("guest")).Certificate()
+
+	conn := newTestVerifiedConn(leaf, inter, root)
+
+	// The identity rules are evaluated against the leaf certificate by default.
+
+	ca, err := auth.NewCertificateAuthenticator(auth.WithCommonNameRegexp("^admin$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ca.VerifyCertificate(conn); ok {
+		t.Error("intermediate certificate should not be matched")
+	}
+
+	ca, err = auth.NewCertificateAuthenticator(
+		auth.WithCommonNameRegexp("^admin$"),
+		auth.WithLeafCertificateOnly(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ca.VerifyCertificate(conn); !ok {
+		t.Error("intermediate certificate should be matched")
+	}
+
+	// The issuer rules are evaluated against the verified chains.
+
+	ca, err = auth.NewCertificateAuthenticator(
+		auth.WithCommonNameRegexp("^guest$"),
+		auth.WithIssuerCommonNameRegexp("^Root CA$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ca.VerifyCertificate(conn); !ok {
+		t.Error("issuer should be matched")
+	}
+	if ok, _ := ca.VerifyCertificate(newTestConn(leaf, inter, root)); ok {
+		t.Error("unverified chain should not be matched")
+	}
+
+	ca, err = auth.NewCertificateAuthenticator(
+		auth.WithCommonNameRegexp("^guest$"),
+		auth.WithIssuerCertificates(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ca.VerifyCertificate(conn); ok {
+		t.Error("leaf certificate should not be matched as an issuer")
+	}
+}
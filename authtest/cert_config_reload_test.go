@@ -0,0 +1,210 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+
+	// writeKeyPair writes a new server key pair, and moves the modification time forward to be detected by polling.
+	modTime := time.Now()
+	writeKeyPair := func(cn string) {
+		cert := newTestServerCertificate(t, nil, certgen.WithCommonName(cn))
+		modTime = modTime.Add(time.Second)
+		for file, data := range map[string][]byte{certFile: cert.CertificatePEM(), keyFile: cert.KeyPEM()} {
+			if err := os.WriteFile(file, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(file, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeKeyPair("server-1")
+
+	conf := tls.NewCertConfig()
+	conf.SetClientAuthType(gotls.NoClientCert)
+	if err := conf.SetServerCertFile(certFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.SetServerKeyFile(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	reloadErrs := []error{}
+	conf.SetReloadInterval(time.Millisecond)
+	conf.SetReloadErrorHandler(func(err error) {
+		reloadErrs = append(reloadErrs, err)
+	})
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverName := func() string {
+		t.Helper()
+		name := ""
+		_, err := testHandshake(t, serverConfig, &gotls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection: func(state gotls.ConnectionState) error {
+				name = state.PeerCertificates[0].Subject.CommonName
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	if name := serverName(); name != "server-1" {
+		t.Errorf("%s != %s", name, "server-1")
+	}
+
+	// The rotated key pair is served without restart.
+
+	writeKeyPair("server-2")
+	time.Sleep(5 * time.Millisecond)
+	if name := serverName(); name != "server-2" {
+		t.Errorf("%s != %s", name, "server-2")
+	}
+
+	// A broken key file is reported, and the current key pair is kept.
+
+	modTime = modTime.Add(time.Second)
+	if err := os.WriteFile(keyFile, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keyFile, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if name := serverName(); name != "server-2" {
+		t.Errorf("%s != %s", name, "server-2")
+	}
+	if len(reloadErrs) != 1 {
+		t.Errorf("%d reload errors", len(reloadErrs))
+	}
+	if err := conf.Reload(); err == nil {
+		t.Error("broken key file should be rejected")
+	}
+
+	// The certificate can also be served by GetCertificate.
+
+	writeKeyPair("server-3")
+	if err := conf.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := conf.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.Subject.CommonName != "server-3" {
+		t.Errorf("%s != %s", leaf.Subject.CommonName, "server-3")
+	}
+}
+
+func TestCertConfigReloadRetry(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+
+	modTime := time.Now()
+	writeFile := func(file string, data []byte) {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newKeyPair := func(cn string) ([]byte, []byte) {
+		cert := newTestServerCertificate(t, nil, certgen.WithCommonName(cn))
+		return cert.CertificatePEM(), cert.KeyPEM()
+	}
+	cert, key := newKeyPair("server-1")
+	writeFile(certFile, cert)
+	writeFile(keyFile, key)
+
+	conf := tls.NewCertConfig()
+	conf.SetClientAuthType(gotls.NoClientCert)
+	if err := conf.SetServerCertFile(certFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.SetServerKeyFile(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	reloadErrs := []error{}
+	conf.SetReloadInterval(time.Millisecond)
+	conf.SetReloadErrorHandler(func(err error) {
+		reloadErrs = append(reloadErrs, err)
+	})
+	if _, err := conf.TLSConfig(); err != nil {
+		t.Fatal(err)
+	}
+	serverName := func() string {
+		t.Helper()
+		time.Sleep(5 * time.Millisecond)
+		cert, err := conf.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaf.Subject.CommonName
+	}
+
+	// The first poll reads a partially written key file and fails. The completed key file has the same size and
+	// modification time, as on a file system with a coarse timestamp resolution, and is picked up by a later poll.
+
+	modTime = modTime.Add(time.Second)
+	cert, key = newKeyPair("server-2")
+	writeFile(certFile, cert)
+	partial := make([]byte, len(key))
+	copy(partial, key[:len(key)/2])
+	writeFile(keyFile, partial)
+	if name := serverName(); name != "server-1" {
+		t.Errorf("%s != %s", name, "server-1")
+	}
+	if len(reloadErrs) != 1 {
+		t.Errorf("%d reload errors", len(reloadErrs))
+	}
+
+	writeFile(keyFile, key)
+	if name := serverName(); name != "server-2" {
+		t.Errorf("%s != %s", name, "server-2")
+	}
+	if len(reloadErrs) != 1 {
+		t.Errorf("%d reload errors", len(reloadErrs))
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+)
+
+func TestCertConfigFiles(t *testing.T) {
+	ca := newTestCA(t, nil)
+	server := newTestServerCertificate(t, ca)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	caCertFile := filepath.Join(dir, "ca.pem")
+	if err := server.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(caCertFile, ca.CertificatePEM(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := tls.NewCertConfig()
+	if err := conf.SetServerCertFile(certFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.SetServerKeyFile(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.SetRootCertFiles(caCertFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conf.TLSConfig(); err != nil {
+		t.Error(err)
+	}
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+	rootCerts, err := conf.RootCertificates()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rootCerts) != 1 {
+		t.Errorf("%d != 1 root certificates", len(rootCerts))
+	}
+}
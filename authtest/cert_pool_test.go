@@ -0,0 +1,144 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertConfigRootCerts(t *testing.T) {
+	ca := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	other := newTestCA(t, nil, certgen.WithCommonName("Other CA"))
+	server := newTestServerCertificate(t, ca, certgen.WithCommonName("server"))
+	otherKey := newTestCertificate(t, nil, certgen.WithCommonName("other")).KeyPEM()
+
+	dir := t.TempDir()
+	writeFile := func(name string, data []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	pemFile := writeFile("ca.pem", ca.CertificatePEM())
+	derFile := writeFile("other.der", other.Certificate().Raw)
+	typoFile := writeFile("typo.pem", []byte(strings.Replace(string(ca.CertificatePEM()), "BEGIN", "BEGN", 1)))
+	keyFile := writeFile("key.pem", server.KeyPEM())
+	broken := ca.CertificatePEM()
+	broken = append(broken, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("broken")})...)
+	brokenFile := writeFile("broken.pem", broken)
+
+	// PEM and DER files are loaded.
+
+	conf := tls.NewCertConfig()
+	if err := conf.SetRootCertFiles(pemFile, derFile); err != nil {
+		t.Fatal(err)
+	}
+	rootCerts, err := conf.RootCertificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rootCerts) != 2 {
+		t.Errorf("%d != 2 root certificates", len(rootCerts))
+	}
+
+	// Invalid files are reported with the file name and the reason.
+
+	if err := conf.SetRootCertFiles(pemFile, typoFile); err == nil || !strings.Contains(err.Error(), typoFile) {
+		t.Errorf("typo'd file should be reported: %v", err)
+	}
+	if err := conf.SetRootCertFiles(keyFile); !errors.Is(err, tls.ErrNoCertificates) {
+		t.Errorf("%v is not %v", err, tls.ErrNoCertificates)
+	}
+	if err := conf.SetRootCertFiles(brokenFile); err == nil || !strings.Contains(err.Error(), "PEM block 1") {
+		t.Errorf("broken block should be reported: %v", err)
+	}
+	conf.SetRootCerts(ca.CertificatePEM(), []byte("garbage"))
+	if _, err := conf.RootCertificates(); err == nil || !strings.Contains(err.Error(), "#1") {
+		t.Errorf("invalid root certificate should be reported: %v", err)
+	}
+
+	// Validate checks the key pair and the chain.
+
+	conf = tls.NewCertConfig()
+	conf.SetServerCert(server.CertificatePEM())
+	conf.SetServerKey(server.KeyPEM())
+	conf.SetRootCerts(ca.CertificatePEM())
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+	conf.SetRootCerts(other.CertificatePEM())
+	if err := conf.Validate(); err == nil {
+		t.Error("chain to an unrelated root should be rejected")
+	}
+	conf.SetRootCerts(ca.CertificatePEM())
+	conf.SetServerKey(otherKey)
+	if err := conf.Validate(); err == nil {
+		t.Error("mismatched key should be rejected")
+	}
+}
+
+func TestCertConfigClientCAs(t *testing.T) {
+	rootCA := newTestCA(t, nil, certgen.WithCommonName("Root CA"))
+	clientCA := newTestCA(t, nil, certgen.WithCommonName("Client CA"))
+	server := newTestServerCertificate(t, rootCA, certgen.WithCommonName("server"))
+	newClientPair := func(issuer *certgen.CA) gotls.Certificate {
+		return newTestCertificate(t, issuer, certgen.WithCommonName("client")).TLSCertificate()
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetServerCert(server.CertificatePEM())
+	conf.SetServerKey(server.KeyPEM())
+	conf.SetRootCerts(rootCA.CertificatePEM())
+	conf.SetClientCACerts(clientCA.CertificatePEM())
+	conf.SetSystemRootCAs(true)
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverConfig.ClientCAs.Equal(serverConfig.RootCAs) {
+		t.Error("client CAs and root CAs should be distinct")
+	}
+	if err := conf.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	tests := []struct {
+		name string
+		pair gotls.Certificate
+		ok   bool
+	}{
+		{"client CA", newClientPair(clientCA), true},
+		{"root CA", newClientPair(rootCA), false},
+	}
+	for _, test := range tests {
+		_, err := testHandshake(t, serverConfig, &gotls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []gotls.Certificate{test.pair},
+		})
+		if ok := err == nil; ok != test.ok {
+			t.Errorf("%s: %v != %v (%v)", test.name, ok, test.ok, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+// testConn is a TLS connection stub which returns a fixed connection state.
+type testConn struct {
+	state tls.ConnectionState
+}
+
+func newTestConn(certs ...*x509.Certificate) *testConn {
+	return &testConn{
+		state: tls.ConnectionState{
+			PeerCertificates: certs,
+		},
+	}
+}
+
+func newTestVerifiedConn(chain ...*x509.Certificate) *testConn {
+	return &testConn{
+		state: tls.ConnectionState{
+			PeerCertificates: chain,
+			VerifiedChains:   [][]*x509.Certificate{chain},
+		},
+	}
+}
+
+func (conn *testConn) ConnectionState() tls.ConnectionState {
+	return conn.state
+}
+
+// newTestCA generates a root CA, or an intermediate CA issued by the parent if it is not nil.
+func newTestCA(t *testing.T, parent *certgen.CA, opts ...certgen.Option) *certgen.CA {
+	t.Helper()
+	var ca *certgen.CA
+	var err error
+	if parent == nil {
+		ca, err = certgen.NewCA(opts...)
+	} else {
+		ca, err = parent.NewIntermediateCA(opts...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// newTestCertificate generates a client certificate issued by the CA, or a self-signed server and client certificate if the CA is nil.
+func newTestCertificate(t *testing.T, ca *certgen.CA, opts ...certgen.Option) *certgen.Certificate {
+	t.Helper()
+	var cert *certgen.Certificate
+	var err error
+	if ca == nil {
+		cert, err = certgen.NewSelfSignedCertificate(opts...)
+	} else {
+		cert, err = ca.NewClientCertificate(opts...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// newTestServerCertificate generates a server certificate for localhost issued by the CA, or a self-signed one if the CA is nil.
+func newTestServerCertificate(t *testing.T, ca *certgen.CA, opts ...certgen.Option) *certgen.Certificate {
+	t.Helper()
+	opts = append([]certgen.Option{certgen.WithDNSNames("localhost")}, opts...)
+	if ca == nil {
+		return newTestCertificate(t, nil, opts...)
+	}
+	cert, err := ca.NewServerCertificate(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// testHandshake runs a TLS handshake over a loopback TCP connection and returns the server side connection state.
+func testHandshake(t *testing.T, serverConfig *tls.Config, clientConfig *tls.Config) (tls.ConnectionState, error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	serverResult := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverResult <- result{tls.ConnectionState{}, err}
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		server := tls.Server(conn, serverConfig)
+		err = server.Handshake()
+		serverResult <- result{server.ConnectionState(), err}
+	}()
+
+	conn, err := net.DialTimeout("tcp", ln.Addr().String(), 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	client := tls.Client(conn, clientConfig)
+	clientErr := client.Handshake()
+	if clientErr == nil {
+		// In TLS 1.3, the server verifies the client certificate after the client handshake has completed,
+		// so read from the connection until the server closes it to receive an alert sent by the server.
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		_, readErr := client.Read(make([]byte, 1))
+		var netErr net.Error
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !(errors.As(readErr, &netErr) && netErr.Timeout()) {
+			clientErr = readErr
+		}
+	}
+	conn.Close()
+
+	res := <-serverResult
+	if res.err != nil {
+		return tls.ConnectionState{}, res.err
+	}
+	if clientErr != nil {
+		return tls.ConnectionState{}, clientErr
+	}
+	return res.state, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertgen(t *testing.T) {
+	root, err := certgen.NewCA(certgen.WithCommonName("Test Root CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := root.NewIntermediateCA(certgen.WithCommonName("Test Issuing CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.NewServerCertificate(certgen.WithDNSNames("localhost", "127.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ca.NewClientCertificate(
+		certgen.WithCommonName("alice"),
+		certgen.WithEmailAddresses("alice@example.com"),
+		certgen.WithURIs("spiffe://example.com/alice"),
+		certgen.WithValidityPeriod(time.Hour),
+		certgen.WithKeyType(certgen.Ed25519))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cn := server.Certificate().Subject.CommonName; cn != "localhost" {
+		t.Errorf("%s != localhost", cn)
+	}
+	if n := len(server.Certificate().IPAddresses); n != 1 {
+		t.Errorf("%d != 1 IP addresses", n)
+	}
+	if n := len(server.TLSCertificate().Certificate); n != 2 {
+		t.Errorf("%d != 2 certificates in the chain", n)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	if err := server.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	serverConf := tls.NewCertConfig()
+	if err := serverConf.SetServerCertFile(certFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := serverConf.SetServerKeyFile(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	serverConf.SetRootCerts(root.CertificatePEM())
+	if err := serverConf.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	serverConfig, err := serverConf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientConf := tls.NewClientCertConfig()
+	clientConf.SetClientCert(client.CertificatePEM())
+	clientConf.SetClientKey(client.KeyPEM())
+	clientConf.SetRootCerts(root.CertificatePEM())
+	clientConf.SetServerName("localhost")
+	clientConfig, err := clientConf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := testHandshake(t, serverConfig, clientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certAuth, err := auth.NewCertificateAuthenticator(
+		auth.WithCommonNameRegexp("^alice$"),
+		auth.WithIssuerCommonNameRegexp("^Test Root CA$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := certAuth.VerifyCertificate(&testConn{state: state}); !ok {
+		t.Errorf("generated client certificate should be authenticated: %v", err)
+	}
+
+	// The key types are selectable.
+
+	for _, keyType := range []certgen.KeyType{certgen.ECDSAP256, certgen.ECDSAP384, certgen.RSA2048, certgen.Ed25519} {
+		cert, err := certgen.NewSelfSignedCertificate(certgen.WithDNSNames("localhost"), certgen.WithKeyType(keyType))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if _, err := gotls.X509KeyPair(cert.CertificatePEM(), cert.KeyPEM()); err != nil {
+			t.Error(err)
+		}
+		if _, err := cert.Certificate().Verify(x509.VerifyOptions{
+			Roots:   newTestCertPool(cert.Certificate()),
+			DNSName: "localhost",
+		}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func newTestCertPool(certs ...*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestClientCertConfig(t *testing.T) {
+	corpCA := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	serverCert := newTestServerCertificate(t, corpCA, certgen.WithCommonName("db"), certgen.WithDNSNames("db.example.com"))
+	clientCert := newTestCertificate(t, corpCA, certgen.WithCommonName("replica"))
+	ca, server := corpCA.Certificate(), serverCert.Certificate()
+
+	serverConf := tls.NewCertConfig()
+	serverConf.SetServerCert(serverCert.CertificatePEM())
+	serverConf.SetServerKey(serverCert.KeyPEM())
+	serverConf.SetRootCerts(corpCA.CertificatePEM())
+	serverConfig, err := serverConf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newClientConf := func() tls.ClientCertConfig {
+		conf := tls.NewClientCertConfig()
+		conf.SetClientCert(clientCert.CertificatePEM())
+		conf.SetClientKey(clientCert.KeyPEM())
+		conf.SetRootCerts(corpCA.CertificatePEM())
+		conf.SetServerName("db.example.com")
+		return conf
+	}
+
+	tests := []struct {
+		name  string
+		setup func(conf tls.ClientCertConfig)
+		ok    bool
+	}{
+		{"verified", func(conf tls.ClientCertConfig) {}, true},
+		{"server name", func(conf tls.ClientCertConfig) { conf.SetServerName("cache.example.com") }, false},
+		{"no client certificate", func(conf tls.ClientCertConfig) { conf.SetClientCert(nil); conf.SetClientKey(nil) }, false},
+		{"pinned certificate", func(conf tls.ClientCertConfig) { conf.SetPinnedCertificates(tls.CertificateFingerprint(server)) }, true},
+		{"pinned public key", func(conf tls.ClientCertConfig) { conf.SetPinnedPublicKeys(tls.PublicKeyFingerprint(server)) }, true},
+		{"other pin", func(conf tls.ClientCertConfig) { conf.SetPinnedCertificates(tls.CertificateFingerprint(ca)) }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := newClientConf()
+			test.setup(conf)
+			clientConfig, err := conf.TLSConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, err := testHandshake(t, serverConfig, clientConfig)
+			if ok := err == nil; ok != test.ok {
+				t.Errorf("%v != %v (%v)", ok, test.ok, err)
+			}
+			if err == nil && state.PeerCertificates[0].Subject.CommonName != "replica" {
+				t.Errorf("%s != replica", state.PeerCertificates[0].Subject.CommonName)
+			}
+		})
+	}
+}
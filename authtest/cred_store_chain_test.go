@@ -0,0 +1,115 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+)
+
+type testFailingCredentialStore struct{}
+
+func (store *testFailingCredentialStore) LookupCredential(q auth.Query) (auth.Credential, bool, error) {
+	return nil, false, errors.New("unavailable")
+}
+
+func newTestCredentialStore(group string, usernames ...string) *testCredentialStore {
+	store := &testCredentialStore{creds: map[string]auth.Credential{}}
+	for _, username := range usernames {
+		store.creds[username] = auth.NewCredential(
+			auth.WithCredentialGroup(group),
+			auth.WithCredentialUsername(username),
+			auth.WithCredentialPassword(group),
+		)
+	}
+	return store
+}
+
+func TestCredentialStoreChain(t *testing.T) {
+	services := newTestCredentialStore("service", "backup", "alice")
+	humans := newTestCredentialStore("human", "alice", "bob")
+
+	lookup := func(store auth.CredentialStore, group string, username string) (auth.Credential, bool, error) {
+		t.Helper()
+		q, err := auth.NewQuery(auth.WithQueryGroup(group), auth.WithQueryUsername(username))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store.LookupCredential(q)
+	}
+
+	store, err := auth.NewCredentialStoreChain(
+		auth.WithCredentialStore(services),
+		auth.WithCredentialStore(humans),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred, ok, err := lookup(store, "", "alice"); !ok || err != nil || cred.Group() != "service" {
+		t.Errorf("the first store should win: %v %v %v", cred, ok, err)
+	}
+	if cred, ok, err := lookup(store, "", "bob"); !ok || err != nil || cred.Group() != "human" {
+		t.Errorf("the second store should be consulted: %v %v %v", cred, ok, err)
+	}
+	if _, ok, err := lookup(store, "", "carol"); ok || err != nil {
+		t.Errorf("unknown user should not be found: %v %v", ok, err)
+	}
+
+	store, err = auth.NewCredentialStoreChain(
+		auth.WithCredentialStore(services, "service"),
+		auth.WithCredentialStore(humans, "human"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred, ok, err := lookup(store, "human", "alice"); !ok || err != nil || cred.Group() != "human" {
+		t.Errorf("the store out of the group should be skipped: %v %v %v", cred, ok, err)
+	}
+	if _, ok, err := lookup(store, "service", "bob"); ok || err != nil {
+		t.Errorf("the store out of the group should be skipped: %v %v", ok, err)
+	}
+
+	store, err = auth.NewCredentialStoreChain(
+		auth.WithCredentialStorePrecedence(auth.CredentialStoreUnique),
+		auth.WithCredentialStore(services),
+		auth.WithCredentialStore(humans),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := lookup(store, "", "alice"); ok || !errors.Is(err, auth.ErrDuplicateCredential) {
+		t.Errorf("duplicate user should be rejected: %v %v", ok, err)
+	}
+	if cred, ok, err := lookup(store, "", "backup"); !ok || err != nil || cred.Group() != "service" {
+		t.Errorf("unique user should be found: %v %v %v", cred, ok, err)
+	}
+
+	store, err = auth.NewCredentialStoreChain(
+		auth.WithCredentialStore(&testFailingCredentialStore{}),
+		auth.WithCredentialStore(humans),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := lookup(store, "", "bob"); ok || err == nil {
+		t.Errorf("store error should stop the lookup: %v %v", ok, err)
+	}
+
+	if _, err := auth.NewCredentialStoreChain(auth.WithCredentialStore(nil)); err == nil {
+		t.Error("nil store should be rejected")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestFileCredentialStore(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"users.yaml": `users:
+  - group: admin
+    username: alice
+    password: "` + string(hash) + `"
+    mechanisms: [plain]
+  - group: admin
+    username: bob
+    password: plain
+    enabled: false
+`,
+		"users.json": `{
+  "users": [
+    {"group": "admin", "username": "alice", "password": "` + string(hash) + `", "mechanisms": ["PLAIN"]},
+    {"group": "admin", "username": "bob", "password": "plain", "enabled": false}
+  ]
+}
+`,
+		"users.toml": `[[users]]
+group = "admin"
+username = "alice"
+password = "` + string(hash) + `"
+mechanisms = ["PLAIN"]
+
+[[users]]
+group = "admin"
+username = "bob"
+password = "plain"
+enabled = false
+`,
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			store, err := auth.NewFileCredentialStore(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mgr := auth.NewManager()
+			mgr.SetCredentialAuthenticator(auth.NewPasswordHashAuthenticator())
+			mgr.SetCredentialStore(store)
+
+			verify := func(username string, password string, mech string) bool {
+				t.Helper()
+				q, err := auth.NewQuery(
+					auth.WithQueryUsername(username),
+					auth.WithQueryPassword(password),
+					auth.WithQueryMechanism(mech),
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ok, _ := mgr.VerifyCredential(nil, q)
+				return ok
+			}
+			if !verify("alice", "secret", "PLAIN") {
+				t.Error("valid password should be accepted")
+			}
+			if verify("alice", "invalid", "PLAIN") {
+				t.Error("invalid password should be rejected")
+			}
+			if verify("alice", "secret", "SCRAM-SHA-256") {
+				t.Error("mechanism not allowed should be rejected")
+			}
+			if verify("alice", "secret", "") {
+				t.Error("query without a mechanism should be rejected for a restricted user")
+			}
+			if verify("bob", "plain", "PLAIN") {
+				t.Error("disabled user should be rejected")
+			}
+		})
+	}
+}
+
+func TestFileCredentialStoreErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"syntax.yaml", "users:\n  - username: alice\n    password: [\n", 3},
+		{"unknown.yaml", "users:\n  - username: alice\n    pasword: secret\n", 3},
+		{"duplicate.yaml", "users:\n  - username: alice\n    password: a\n  - username: alice\n    password: b\n", 4},
+		{"syntax.json", "{\n  \"users\": [\n    {\"username\": \"alice\",}\n  ]\n}\n", 3},
+		{"nopassword.json", "{\n  \"users\": [\n    {\"username\": \"alice\", \"password\": \"a\"},\n    {\"username\": \"bob\"}\n  ]\n}\n", 4},
+		{"syntax.toml", "[[users]]\nusername = \"alice\"\npassword = \n", 3},
+		{"hash.toml", "[[users]]\nusername = \"alice\"\npassword = \"a\"\n\n[[users]]\nusername = \"bob\"\npassword = \"$2y$invalid\"\n", 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), test.name)
+			if err := os.WriteFile(file, []byte(test.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := auth.NewFileCredentialStore(file)
+			var fileErr *auth.CredentialFileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fileErr.Line != test.line {
+				t.Errorf("line %d != %d: %v", fileErr.Line, test.line, err)
+			}
+		})
+	}
+}
+
+func TestFileCredentialStoreReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.yaml")
+	write := func(data string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lookup := func(store auth.CredentialStore, username string) bool {
+		t.Helper()
+		q, err := auth.NewQuery(auth.WithQueryUsername(username))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok, err := store.LookupCredential(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	now := time.Now()
+	write("users:\n  - username: alice\n    password: secret\n", now.Add(-time.Hour))
+	var reloadErr error
+	store, err := auth.NewFileCredentialStore(file,
+		auth.WithCredentialFileReloadInterval(time.Nanosecond),
+		auth.WithCredentialFileReloadErrorHandler(func(err error) { reloadErr = err }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lookup(store, "alice") || lookup(store, "bob") {
+		t.Fatal("unexpected credentials")
+	}
+
+	write("users:\n  - username: bob\n    password: secret\n", now.Add(-time.Minute))
+	if lookup(store, "alice") || !lookup(store, "bob") {
+		t.Error("changed file should be reloaded")
+	}
+
+	write("users:\n  - username: carol\n", now)
+	if !lookup(store, "bob") || lookup(store, "carol") {
+		t.Error("invalid file should keep the current credentials")
+	}
+	if reloadErr == nil {
+		t.Error("reload error should be reported")
+	}
+}
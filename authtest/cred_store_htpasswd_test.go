@@ -0,0 +1,94 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+)
+
+func TestHtpasswdAuthenticator(t *testing.T) {
+	data := `# generated by htpasswd
+alice:$2y$04$wSszWvBMCQMC6i6ZyMJckOxBblmdm5GQGC7BcD7gMZM0iujpRVT9O
+bob:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=
+
+carol:$apr1$abcdefgh$h9FWgUz3n9YxylKLlR5SQ/
+dave:$1$xyz$Qia9Wq6FxQkYcwNWMk/RM0
+`
+	file := filepath.Join(t.TempDir(), ".htpasswd")
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ca, err := auth.NewHtpasswdAuthenticator(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := auth.NewManager()
+	mgr.SetCredentialAuthenticator(ca)
+
+	for _, username := range []string{"alice", "bob", "carol", "dave"} {
+		for _, password := range []string{"secret", "invalid"} {
+			q, err := auth.NewQuery(
+				auth.WithQueryGroup("db"),
+				auth.WithQueryUsername(username),
+				auth.WithQueryPassword(password),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := mgr.VerifyCredential(nil, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != (password == "secret") {
+				t.Errorf("%s:%s %v", username, password, ok)
+			}
+		}
+	}
+}
+
+func TestHtpasswdCredentialStoreErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		line int
+	}{
+		{"alice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\nbob\n", 2},
+		{"alice:{SHA}invalid\n", 1},
+		{"alice:$apr1$salt\n", 1},
+		{"alice:$6$salt$hash\n", 1},
+		{"alice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\nbob:rBrrdPSfXnTOo\n", 2},
+		{"alice:secret\n", 1},
+		{"\nalice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\nalice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\n", 3},
+	}
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "users")
+		if err := os.WriteFile(file, []byte(test.data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := auth.NewHtpasswdCredentialStore(file)
+		var fileErr *auth.CredentialFileError
+		if !errors.As(err, &fileErr) {
+			t.Errorf("%q: unexpected error: %v", test.data, err)
+			continue
+		}
+		if fileErr.Line != test.line {
+			t.Errorf("%q: line %d != %d: %v", test.data, fileErr.Line, test.line, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth"
+)
+
+func TestMemoryCredentialStore(t *testing.T) {
+	newCred := func(group string, username string, password string) auth.Credential {
+		return auth.NewCredential(
+			auth.WithCredentialGroup(group),
+			auth.WithCredentialUsername(username),
+			auth.WithCredentialPassword(password),
+		)
+	}
+	lookup := func(store auth.CredentialStore, group string, username string) (auth.Credential, bool, error) {
+		t.Helper()
+		q, err := auth.NewQuery(auth.WithQueryGroup(group), auth.WithQueryUsername(username))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store.LookupCredential(q)
+	}
+
+	store, err := auth.NewMemoryCredentialStore(newCred("admin", "alice", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddCredential(newCred("admin", "alice", "other")); !errors.Is(err, auth.ErrCredentialExists) {
+		t.Errorf("duplicate credential should be rejected: %v", err)
+	}
+	if err := store.AddCredential(newCred("user", "bob", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if cred, ok, err := lookup(store, "", "bob"); !ok || err != nil || cred.Group() != "user" {
+		t.Errorf("%v %v %v", cred, ok, err)
+	}
+	if _, ok, err := lookup(store, "admin", "bob"); ok || err != nil {
+		t.Errorf("credential of another group should not be found: %v %v", ok, err)
+	}
+
+	if err := store.UpdateCredential(newCred("user", "bob", "changed")); err != nil {
+		t.Fatal(err)
+	}
+	if cred, _, _ := lookup(store, "user", "bob"); cred.Password() != "changed" {
+		t.Errorf("password should be updated: %v", cred.Password())
+	}
+	if err := store.UpdateCredential(newCred("user", "carol", "secret")); !errors.Is(err, auth.ErrCredentialNotFound) {
+		t.Errorf("unknown credential should not be updated: %v", err)
+	}
+
+	snapshot := store.Snapshot()
+	if err := store.AddCredential(newCred("user", "alice", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := lookup(store, "", "alice"); ok || !errors.Is(err, auth.ErrDuplicateCredential) {
+		t.Errorf("ambiguous username should be rejected: %v %v", ok, err)
+	}
+	if creds := store.Credentials("user"); len(creds) != 2 || creds[0].Username() != "alice" {
+		t.Errorf("unexpected credentials: %v", creds)
+	}
+	if err := store.RemoveCredential("user", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RemoveCredential("user", "bob"); !errors.Is(err, auth.ErrCredentialNotFound) {
+		t.Errorf("removed credential should not be removed again: %v", err)
+	}
+
+	if err := store.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	creds := store.Credentials()
+	if len(creds) != 2 || creds[0].Username() != "alice" || creds[1].Username() != "bob" {
+		t.Errorf("unexpected credentials: %v", creds)
+	}
+	if err := store.Restore([]auth.Credential{newCred("", "", "secret")}); err == nil {
+		t.Error("credential without username should be rejected")
+	}
+	if len(store.Credentials()) != 2 {
+		t.Error("invalid snapshot should not change the store")
+	}
+}
+
+func TestMemoryCredentialStoreConcurrency(t *testing.T) {
+	store, err := auth.NewMemoryCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := auth.NewManager()
+	mgr.SetCredentialStore(store)
+
+	var wg sync.WaitGroup
+	for n := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			username := fmt.Sprintf("user%d", n)
+			err := store.AddCredential(auth.NewCredential(
+				auth.WithCredentialUsername(username),
+				auth.WithCredentialPassword("secret"),
+			))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			q, err := auth.NewQuery(auth.WithQueryUsername(username), auth.WithQueryPassword("secret"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok, err := mgr.VerifyCredential(nil, q); !ok || err != nil {
+				t.Errorf("%s: %v %v", username, ok, err)
+			}
+			store.Snapshot()
+		}()
+	}
+	wg.Wait()
+	if len(store.Credentials()) != 8 {
+		t.Errorf("unexpected credentials: %v", store.Credentials())
+	}
+}
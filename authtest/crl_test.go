@@ -0,0 +1,129 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func newTestCRL(t *testing.T, issuer *x509.Certificate, issuerKey crypto.Signer, revoked ...*x509.Certificate) []byte {
+	t.Helper()
+	entries := []x509.RevocationListEntry{}
+	for _, cert := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   cert.SerialNumber,
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(time.Now().UnixNano()),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, issuer, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func TestCRL(t *testing.T) {
+	corpCA := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	ca, caKey := corpCA.Certificate(), corpCA.PrivateKey()
+	fakeKey := newTestCA(t, nil, certgen.WithCommonName("Corp CA")).PrivateKey()
+	newClient := func(cn string) (*x509.Certificate, gotls.Certificate) {
+		cert := newTestCertificate(t, corpCA, certgen.WithCommonName(cn))
+		return cert.Certificate(), cert.TLSCertificate()
+	}
+	leaked, leakedPair := newClient("leaked")
+	valid, validPair := newClient("valid")
+
+	crlFile := filepath.Join(t.TempDir(), "crl.pem")
+	if err := os.WriteFile(crlFile, newTestCRL(t, ca, caKey), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := newTestServerCertificate(t, nil)
+	conf := tls.NewCertConfig()
+	conf.SetServerCert(server.CertificatePEM())
+	conf.SetServerKey(server.KeyPEM())
+	conf.SetRootCerts(corpCA.CertificatePEM())
+	if err := conf.SetCRLFiles(crlFile); err != nil {
+		t.Fatal(err)
+	}
+	// A list with the same issuer name which is signed by another key is ignored.
+	conf.SetCRLs(newTestCRL(t, ca, fakeKey, valid))
+	conf.SetCRLReloadInterval(time.Millisecond)
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handshake := func(pair gotls.Certificate) (gotls.ConnectionState, error) {
+		return testHandshake(t, serverConfig, &gotls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []gotls.Certificate{pair},
+		})
+	}
+
+	for _, pair := range []gotls.Certificate{leakedPair, validPair} {
+		if _, err := handshake(pair); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Revoke the leaked certificate and wait for the reload.
+
+	if err := os.WriteFile(crlFile, newTestCRL(t, ca, caKey, leaked), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := handshake(leakedPair); err == nil {
+		t.Error("revoked certificate should be rejected")
+	}
+	state, err := handshake(validPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := auth.NewCertificateAuthenticator(
+		auth.WithCommonNameRegexp(".*"),
+		auth.WithRevocationChecker(conf.RevocationChecker()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := authenticator.VerifyCertificate(&testConn{state: state}); !ok || err != nil {
+		t.Errorf("%v %v", ok, err)
+	}
+	ok, err := authenticator.VerifyCertificate(newTestVerifiedConn(leaked, ca))
+	if ok || !errors.Is(err, tls.ErrRevoked) {
+		t.Errorf("%v %v", ok, err)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertificateExpiry(t *testing.T) {
+	day := 24 * time.Hour
+	root, err := certgen.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := root.NewIntermediateCA(certgen.WithValidityPeriod(60 * day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.NewServerCertificate(certgen.WithDNSNames("localhost"), certgen.WithValidityPeriod(5*day))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiredCA, err := certgen.NewCA(certgen.WithValidity(time.Now().Add(-2*day), time.Now().Add(-day)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetServerCert(server.CertificatePEM())
+	conf.SetServerKey(server.KeyPEM())
+	conf.SetRootCerts(root.CertificatePEM())
+	conf.SetClientCACerts(expiredCA.CertificatePEM())
+
+	expiries, err := conf.CertificateExpiries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := []tls.CertificateRole{}
+	for _, expiry := range expiries {
+		roles = append(roles, expiry.Role)
+	}
+	expectedRoles := []tls.CertificateRole{tls.RoleServer, tls.RoleIntermediate, tls.RoleRoot, tls.RoleClientCA}
+	if len(roles) != len(expectedRoles) {
+		t.Fatalf("%v != %v", roles, expectedRoles)
+	}
+	for n, role := range expectedRoles {
+		if roles[n] != role {
+			t.Errorf("%v != %v", roles, expectedRoles)
+		}
+	}
+	if !expiries[3].Expired() || expiries[0].Expired() {
+		t.Error("only the client CA should be expired")
+	}
+
+	// The monitor notifies each certificate once per threshold.
+
+	var mutex sync.Mutex
+	events := map[tls.CertificateRole]time.Duration{}
+	monitor := tls.NewExpiryMonitor(conf,
+		tls.WithExpiryThresholds(day, 7*day, 30*day),
+		tls.WithExpiryCheckInterval(time.Millisecond),
+		tls.WithExpiryHandler(func(event tls.ExpiryEvent) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if _, ok := events[event.Role]; ok {
+				t.Errorf("%s is notified twice", event.Role)
+			}
+			events[event.Role] = event.Threshold
+		}))
+	if err := monitor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := monitor.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expected := map[tls.CertificateRole]time.Duration{
+		tls.RoleServer:   7 * day,
+		tls.RoleClientCA: 0,
+	}
+	if len(events) != len(expected) {
+		t.Errorf("%v != %v", events, expected)
+	}
+	for role, threshold := range expected {
+		if events[role] != threshold {
+			t.Errorf("%s: %s != %s", role, events[role], threshold)
+		}
+	}
+}
+
+func TestExpiryMonitor(t *testing.T) {
+	day := 24 * time.Hour
+	expiredCA, err := certgen.NewCA(certgen.WithValidity(time.Now().Add(-2*day), time.Now().Add(-day)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validCA, err := certgen.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetClientCACerts(expiredCA.CertificatePEM())
+
+	// The check interval must be positive.
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		monitor := tls.NewExpiryMonitor(conf, tls.WithExpiryCheckInterval(interval))
+		if err := monitor.Start(); err == nil {
+			t.Errorf("%s: invalid check interval should be rejected", interval)
+		}
+	}
+
+	// Start and Stop can be called concurrently.
+
+	monitor := tls.NewExpiryMonitor(conf, tls.WithExpiryCheckInterval(time.Millisecond))
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := monitor.Start(); err != nil {
+				t.Error(err)
+			}
+			if err := monitor.Stop(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A certificate which is rotated out and back is notified again.
+
+	notified := 0
+	monitor = tls.NewExpiryMonitor(conf, tls.WithExpiryHandler(func(event tls.ExpiryEvent) {
+		notified++
+	}))
+	for n, ca := range []*certgen.CA{expiredCA, validCA, expiredCA} {
+		conf.SetClientCACerts(ca.CertificatePEM())
+		if err := monitor.Check(); err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 && notified != 1 {
+			t.Errorf("%d notifications", notified)
+		}
+	}
+	if notified != 2 {
+		t.Errorf("%d notifications", notified)
+	}
+}
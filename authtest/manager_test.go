@@ -18,8 +18,97 @@ import (
 	"testing"
 
 	"github.com/cybergarage/go-authenticator/auth"
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
 )
 
 func TestManager(t *testing.T) {
 	auth.NewManager()
 }
+
+type testCredentialStore struct {
+	creds map[string]auth.Credential
+}
+
+func (store *testCredentialStore) LookupCredential(q auth.Query) (auth.Credential, bool, error) {
+	cred, ok := store.creds[q.Username()]
+	return cred, ok, nil
+}
+
+func TestManagerPrincipal(t *testing.T) {
+	mgr := auth.NewManager()
+	mgr.SetCredentialStore(&testCredentialStore{
+		creds: map[string]auth.Credential{
+			"alice": auth.NewCredential(
+				auth.WithCredentialGroup("admin"),
+				auth.WithCredentialUsername("alice"),
+				auth.WithCredentialPassword("secret"),
+			),
+		},
+	})
+
+	q, err := auth.NewQuery(
+		auth.WithQueryGroup("admin"),
+		auth.WithQueryUsername("alice"),
+		auth.WithQueryPassword("secret"),
+		auth.WithQueryMechanism("PLAIN"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok, err := mgr.VerifyCredentialPrincipal(nil, q)
+	if !ok || err != nil {
+		t.Fatalf("%v %v", ok, err)
+	}
+	if p.Username() != "alice" || p.Group() != "admin" || p.Mechanism() != "PLAIN" || p.AuthMethod() != auth.AuthMethodCredential {
+		t.Errorf("unexpected principal: %v", p)
+	}
+
+	q.SetGroup("root")
+	p, ok, err = mgr.VerifyCredentialPrincipal(nil, q)
+	if !ok || err != nil {
+		t.Fatalf("%v %v", ok, err)
+	}
+	if p.Group() != "admin" {
+		t.Errorf("group should be taken from the credential: %s", p.Group())
+	}
+
+	q.SetPassword("invalid")
+	if _, ok, _ := mgr.VerifyCredentialPrincipal(nil, q); ok {
+		t.Error("invalid password should be rejected")
+	}
+
+	leaf := newTestCertificate(t, nil, certgen.WithCommonName("bob")).Certificate()
+	ca, err := auth.NewCertificateAuthenticator(auth.WithCommonNameRegexp("^bob$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetCertificateAuthenticator(ca)
+	p, ok, err = mgr.VerifyCertificatePrincipal(newTestConn(leaf))
+	if !ok || err != nil {
+		t.Fatalf("%v %v", ok, err)
+	}
+	if p.Username() != "bob" || p.AuthMethod() != auth.AuthMethodCertificate || p.Certificate() != leaf {
+		t.Errorf("unexpected principal: %v", p)
+	}
+
+	mgr.SetCertificateAuthenticator(&testAcceptAuthenticator{})
+	q, err = auth.NewQuery(auth.WithQueryUsername("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := mgr.VerifyCertificateQuery(newTestConn(leaf), q); !ok || err != nil {
+		t.Errorf("certificate query should be accepted: %v %v", ok, err)
+	}
+	q.SetGroup("admin")
+	if _, ok, _ := mgr.VerifyCertificateQuery(newTestConn(leaf), q); ok {
+		t.Error("certificate query with a group should be rejected without mapping")
+	}
+}
+
+// testAcceptAuthenticator is a certificate authenticator which accepts every client and does not resolve the principal.
+type testAcceptAuthenticator struct{}
+
+func (a *testAcceptAuthenticator) VerifyCertificate(conn tls.Conn) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestOCSPChecker(t *testing.T) {
+	corpCA := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	ca, caKey := corpCA.Certificate(), corpCA.PrivateKey()
+	newClient := func(cn string) *x509.Certificate {
+		return newTestCertificate(t, corpCA, certgen.WithCommonName(cn)).Certificate()
+	}
+	good := newClient("good")
+	revoked := newClient("revoked")
+	unknown := newClient("unknown")
+	expired := newClient("expired")
+	future := newClient("future")
+
+	var requests atomic.Int32
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		tmpl := ocsp.Response{
+			SerialNumber: req.SerialNumber,
+			Status:       ocsp.Good,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+		switch {
+		case req.SerialNumber.Cmp(revoked.SerialNumber) == 0:
+			tmpl.Status = ocsp.Revoked
+			tmpl.RevokedAt = time.Now().Add(-time.Minute)
+		case req.SerialNumber.Cmp(unknown.SerialNumber) == 0:
+			tmpl.Status = ocsp.Unknown
+		case req.SerialNumber.Cmp(expired.SerialNumber) == 0:
+			tmpl.ThisUpdate = time.Now().Add(-2 * time.Hour)
+			tmpl.NextUpdate = time.Now().Add(-time.Hour)
+		case req.SerialNumber.Cmp(future.SerialNumber) == 0:
+			tmpl.ThisUpdate = time.Now().Add(time.Hour)
+			tmpl.NextUpdate = time.Now().Add(2 * time.Hour)
+		}
+		res, err := ocsp.CreateResponse(ca, ca, tmpl, caKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(res)
+	}))
+	defer responder.Close()
+
+	hardFail := tls.NewOCSPChecker(
+		tls.WithOCSPResponder(responder.URL),
+		tls.WithOCSPHTTPClient(responder.Client()))
+
+	if err := hardFail.CheckRevocation([]*x509.Certificate{good, ca}); err != nil {
+		t.Error(err)
+	}
+	if err := hardFail.CheckRevocation([]*x509.Certificate{good, ca}); err != nil {
+		t.Error(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("response should be cached: %d requests", n)
+	}
+	if err := hardFail.CheckRevocation([]*x509.Certificate{revoked, ca}); !errors.Is(err, tls.ErrRevoked) {
+		t.Errorf("revoked certificate should be rejected: %v", err)
+	}
+	if err := hardFail.CheckRevocation([]*x509.Certificate{unknown, ca}); !errors.Is(err, tls.ErrOCSP) {
+		t.Errorf("unknown certificate should be rejected in hard-fail mode: %v", err)
+	}
+
+	for _, cert := range []*x509.Certificate{expired, future} {
+		before := requests.Load()
+		for range 2 {
+			if err := hardFail.CheckRevocation([]*x509.Certificate{cert, ca}); !errors.Is(err, tls.ErrOCSP) {
+				t.Errorf("stale response should be rejected in hard-fail mode: %v", err)
+			}
+		}
+		if n := requests.Load() - before; n != 2 {
+			t.Errorf("stale response should not be cached: %d requests", n)
+		}
+	}
+	skewed := tls.NewOCSPChecker(
+		tls.WithOCSPResponder(responder.URL),
+		tls.WithOCSPHTTPClient(responder.Client()),
+		tls.WithOCSPClockSkew(2*time.Hour))
+	if err := skewed.CheckRevocation([]*x509.Certificate{expired, ca}); err != nil {
+		t.Errorf("response within the clock skew should be accepted: %v", err)
+	}
+
+	softFail := tls.NewOCSPChecker(
+		tls.WithOCSPResponder(responder.URL),
+		tls.WithOCSPHTTPClient(responder.Client()),
+		tls.WithOCSPSoftFail(true))
+	if err := softFail.CheckRevocation([]*x509.Certificate{unknown, ca}); err != nil {
+		t.Errorf("unknown certificate should be accepted in soft-fail mode: %v", err)
+	}
+	if err := softFail.CheckRevocation([]*x509.Certificate{expired, ca}); err != nil {
+		t.Errorf("stale response should be accepted in soft-fail mode: %v", err)
+	}
+	if err := softFail.CheckRevocation([]*x509.Certificate{revoked, ca}); !errors.Is(err, tls.ErrRevoked) {
+		t.Errorf("revoked certificate should be rejected in soft-fail mode: %v", err)
+	}
+
+	// The certificates have no OCSP server in the AIA extension.
+	noResponder := tls.NewOCSPChecker()
+	if err := noResponder.CheckRevocation([]*x509.Certificate{good, ca}); !errors.Is(err, tls.ErrOCSP) {
+		t.Errorf("certificate without responder should be rejected in hard-fail mode: %v", err)
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetRevocationCheckers(hardFail)
+	if err := conf.RevocationChecker().CheckRevocation([]*x509.Certificate{revoked, ca}); !errors.Is(err, tls.ErrRevoked) {
+		t.Errorf("revoked certificate should be rejected: %v", err)
+	}
+}
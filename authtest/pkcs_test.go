@@ -0,0 +1,151 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// encryptTestKey encrypts the key as an encrypted PKCS#8 PEM by PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC.
+func encryptTestKey(t *testing.T, key crypto.Signer, passphrase string) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	_, _ = rand.Read(salt)
+	_, _ = rand.Read(iv)
+	dk, err := pbkdf2.Key(sha256.New, passphrase, salt, 2048, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	data := append(der, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	marshal := func(v any) asn1.RawValue {
+		b, err := asn1.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return asn1.RawValue{FullBytes: b}
+	}
+	kdfParams := struct {
+		Salt           []byte
+		IterationCount int
+		KeyLength      int
+		PRF            pkix.AlgorithmIdentifier
+	}{salt, 2048, 32, pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}, Parameters: asn1.NullRawValue}}
+	params := struct {
+		KeyDerivationFunc pkix.AlgorithmIdentifier
+		EncryptionScheme  pkix.AlgorithmIdentifier
+	}{
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}, Parameters: marshal(kdfParams)},
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, Parameters: marshal(iv)},
+	}
+	info := struct {
+		Algorithm     pkix.AlgorithmIdentifier
+		EncryptedData []byte
+	}{
+		pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}, Parameters: marshal(params)},
+		data,
+	}
+	encrypted, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted})
+}
+
+func TestCertConfigEncryptedKey(t *testing.T) {
+	cert := newTestServerCertificate(t, nil, certgen.WithCommonName("server"))
+
+	passphrase := func(s string) tls.PassphraseProvider {
+		return func() ([]byte, error) {
+			return []byte(s), nil
+		}
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetServerCert(cert.CertificatePEM())
+	conf.SetServerKey(encryptTestKey(t, cert.PrivateKey(), "secret"))
+	if _, err := conf.TLSConfig(); err == nil {
+		t.Error("encrypted key without a passphrase provider should be rejected")
+	}
+	conf.SetServerKeyPassphraseProvider(passphrase("wrong"))
+	if _, err := conf.TLSConfig(); !errors.Is(err, tls.ErrIncorrectPassphrase) {
+		t.Errorf("%v is not %v", err, tls.ErrIncorrectPassphrase)
+	}
+	conf.SetServerKeyPassphraseProvider(passphrase("secret"))
+	if _, err := conf.TLSConfig(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCertConfigPKCS12(t *testing.T) {
+	ca := newTestCA(t, nil, certgen.WithCommonName("Corp CA"))
+	cert := newTestServerCertificate(t, ca, certgen.WithCommonName("server"))
+	pfx, err := pkcs12.Modern.Encode(cert.PrivateKey(), cert.Certificate(), []*x509.Certificate{ca.Certificate()}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "server.p12")
+	if err := os.WriteFile(file, pfx, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := tls.NewCertConfig()
+	wrong := func() ([]byte, error) { return []byte("wrong"), nil }
+	if err := conf.SetServerPKCS12File(file, wrong); err == nil {
+		t.Error("incorrect password should be rejected")
+	}
+	secret := func() ([]byte, error) { return []byte("secret"), nil }
+	if err := conf.SetServerPKCS12File(file, secret); err != nil {
+		t.Fatal(err)
+	}
+	conf.SetClientAuthType(gotls.NoClientCert)
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(serverConfig.Certificates[0].Certificate); n != 2 {
+		t.Errorf("%d != 2 certificates in the chain", n)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertConfigTLSPolicy(t *testing.T) {
+	cert := newTestServerCertificate(t, nil, certgen.WithCommonName("server"))
+
+	newConf := func(policyName string) tls.CertConfig {
+		conf := tls.NewCertConfig()
+		conf.SetClientAuthType(gotls.NoClientCert)
+		conf.SetServerCert(cert.CertificatePEM())
+		conf.SetServerKey(cert.KeyPEM())
+		if policyName != "" {
+			policy, err := tls.LookupTLSPolicy(policyName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.SetTLSPolicy(policy)
+		}
+		return conf
+	}
+	tls12Client := func(suites ...uint16) *gotls.Config {
+		return &gotls.Config{
+			InsecureSkipVerify: true,
+			MaxVersion:         gotls.VersionTLS12,
+			CipherSuites:       suites,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy string
+		client *gotls.Config
+		ok     bool
+	}{
+		{"modern tls13", "modern", &gotls.Config{InsecureSkipVerify: true}, true},
+		{"modern tls12", "modern", tls12Client(), false},
+		{"intermediate tls12 gcm", "intermediate", tls12Client(gotls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256), true},
+		{"intermediate tls12 cbc", "intermediate", tls12Client(gotls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA), false},
+		{"default tls12 cbc", "", tls12Client(gotls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serverConfig, err := newConf(test.policy).TLSConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = testHandshake(t, serverConfig, test.client)
+			if ok := err == nil; ok != test.ok {
+				t.Errorf("%v != %v (%v)", ok, test.ok, err)
+			}
+		})
+	}
+
+	// Explicit settings are merged into the generated configuration.
+
+	conf := newConf("intermediate")
+	conf.SetMaxVersion(gotls.VersionTLS12)
+	conf.SetALPNProtocols("postgresql")
+	conf.SetSessionTicketsDisabled(true)
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverConfig.MaxVersion != gotls.VersionTLS12 || !serverConfig.SessionTicketsDisabled || len(serverConfig.CipherSuites) == 0 {
+		t.Errorf("settings are not merged: %+v", serverConfig)
+	}
+	state, err := testHandshake(t, serverConfig, &gotls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"postgresql"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != gotls.VersionTLS12 || state.NegotiatedProtocol != "postgresql" {
+		t.Errorf("%s %s", gotls.VersionName(state.Version), state.NegotiatedProtocol)
+	}
+
+	// Invalid settings are rejected.
+
+	conf = newConf("")
+	conf.SetCipherSuites(gotls.TLS_RSA_WITH_RC4_128_SHA)
+	if _, err := conf.TLSConfig(); err == nil {
+		t.Error("insecure cipher suite should be rejected")
+	}
+	conf = newConf("modern")
+	conf.SetMaxVersion(gotls.VersionTLS12)
+	if _, err := conf.TLSConfig(); err == nil {
+		t.Error("maximum version less than the minimum version should be rejected")
+	}
+	if _, err := tls.LookupTLSPolicy("old"); err == nil {
+		t.Error("unknown policy should be rejected")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024 The go-authenticator Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authtest
+
+import (
+	gotls "crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybergarage/go-authenticator/auth/tls"
+	"github.com/cybergarage/go-authenticator/auth/tls/certgen"
+)
+
+func TestCertConfigSNI(t *testing.T) {
+	newKeyPair := func(cn string, dnsNames ...string) ([]byte, []byte) {
+		cert := newTestCertificate(t, nil, certgen.WithCommonName(cn), certgen.WithDNSNames(dnsNames...))
+		return cert.CertificatePEM(), cert.KeyPEM()
+	}
+
+	conf := tls.NewCertConfig()
+	conf.SetClientAuthType(gotls.NoClientCert)
+	cert, key := newKeyPair("default")
+	conf.SetServerCert(cert)
+	conf.SetServerKey(key)
+	conf.AddServerKeyPair(newKeyPair("wildcard", "*.example.com"))
+	cert, key = newKeyPair("db", "db.example.com")
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "db.crt")
+	keyFile := filepath.Join(dir, "db.key")
+	if err := os.WriteFile(certFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, key, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.AddServerKeyPairFiles(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	cert, key = newKeyPair("cache", "cache.internal")
+	conf.AddServerKeyPair(cert, key, "redis.example.net")
+	serverConfig, err := conf.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		serverName string
+		expected   string
+	}{
+		{"db.example.com", "db"},
+		{"DB.Example.com.", "db"},
+		{"pg.example.com", "wildcard"},
+		{"a.pg.example.com", "default"},
+		{"redis.example.net", "cache"},
+		{"cache.internal", "default"},
+		{"", "default"},
+	}
+	for _, test := range tests {
+		name := ""
+		_, err := testHandshake(t, serverConfig, &gotls.Config{
+			ServerName:         test.serverName,
+			InsecureSkipVerify: true,
+			VerifyConnection: func(state gotls.ConnectionState) error {
+				name = state.PeerCertificates[0].Subject.CommonName
+				return nil
+			},
+		})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if name != test.expected {
+			t.Errorf("%s: %s != %s", test.serverName, name, test.expected)
+		}
+	}
+}
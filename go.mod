@@ -2,6 +2,12 @@ module github.com/cybergarage/go-authenticator
 
 go 1.25
 
-require github.com/cybergarage/go-sasl v1.2.6
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/cybergarage/go-sasl v1.2.6
+	golang.org/x/crypto v0.45.0
+	gopkg.in/yaml.v3 v3.0.1
+	software.sslmate.com/src/go-pkcs12 v0.7.3
+)
 
 require github.com/cybergarage/go-safecast v1.3.5 // indirect